@@ -21,11 +21,11 @@ func MakeBool(b bool) Bool {
 }
 
 func True() Bool {
-	return newBool(C.Py_True).AsBool()
+	return Bool{newObjectRef(C.Py_True)}
 }
 
 func False() Bool {
-	return newBool(C.Py_False).AsBool()
+	return Bool{newObjectRef(C.Py_False)}
 }
 
 func (b Bool) Bool() bool {
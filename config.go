@@ -0,0 +1,281 @@
+package gp
+
+/*
+#include <Python.h>
+
+static PyStatus preInitialize(int isolated, int utf8Mode) {
+	PyPreConfig preconfig;
+	if (isolated) {
+		PyPreConfig_InitIsolatedConfig(&preconfig);
+	} else {
+		PyPreConfig_InitPythonConfig(&preconfig);
+	}
+	if (utf8Mode) {
+		preconfig.utf8_mode = 1;
+	}
+	return Py_PreInitialize(&preconfig);
+}
+
+static void initConfig(PyConfig *config, int isolated) {
+	if (isolated) {
+		PyConfig_InitIsolatedConfig(config);
+	} else {
+		PyConfig_InitPythonConfig(config);
+	}
+	config->parse_argv = 0;
+}
+
+static PyStatus setConfigString(PyConfig *config, wchar_t **field, const char *value) {
+	return PyConfig_SetBytesString(config, field, value);
+}
+
+static PyStatus appendModuleSearchPath(PyConfig *config, const char *path) {
+	wchar_t *wpath = Py_DecodeLocale(path, NULL);
+	if (wpath == NULL) {
+		return PyStatus_NoMemory();
+	}
+	PyStatus status = PyWideStringList_Append(&config->module_search_paths, wpath);
+	PyMem_RawFree(wpath);
+	return status;
+}
+
+static void setModuleSearchPathsSet(PyConfig *config) {
+	config->module_search_paths_set = 1;
+}
+
+static void setHashSeed(PyConfig *config, unsigned long seed) {
+	config->use_hash_seed = 1;
+	config->hash_seed = seed;
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"unsafe"
+)
+
+// Config holds the options of InitializeWithConfig. Its zero value starts
+// Python like the python command does (PyConfig_InitPythonConfig), not with
+// the compat configuration of Initialize: the LC_CTYPE locale is set from the
+// environment, a C locale is coerced to UTF-8 or turns on the UTF-8 mode
+// (PEP 538 and PEP 540), and VIRTUAL_ENV is used.
+type Config struct {
+	// Isolated ignores the environment variables, the user site-packages
+	// directory and the current directory, as `python -I` does.
+	Isolated bool
+	// IgnoreEnvironment ignores the PYTHON* environment variables only, as
+	// `python -E` does. It is implied by Isolated.
+	IgnoreEnvironment bool
+	// Home is the Python installation prefix, like PYTHONHOME.
+	Home string
+	// ProgramName is the program name used to compute the default paths.
+	ProgramName string
+	// Argv sets sys.argv. Its items are not parsed as Python options.
+	Argv []string
+	// ModuleSearchPaths, if not nil, replaces the computed sys.path.
+	ModuleSearchPaths []string
+	// NoSite disables importing the site module, and so site-packages, at
+	// startup, as `python -S` does.
+	NoSite bool
+	// UTF8Mode enables the Python UTF-8 mode, as `python -X utf8` does.
+	UTF8Mode bool
+	// UseHashSeed seeds the hash of str and bytes with HashSeed instead of a
+	// random seed, like PYTHONHASHSEED. A HashSeed of 0 disables hash
+	// randomization.
+	UseHashSeed bool
+	HashSeed    uint32
+	// VirtualEnv is the directory of a virtual environment created by
+	// `python -m venv` or virtualenv. Python then runs as the venv's
+	// interpreter: sys.prefix and sys.exec_prefix are the venv directory and
+	// its site-packages are on sys.path. The venv must be made from the
+	// Python version the program is linked with. If VirtualEnv is empty, the
+	// VIRTUAL_ENV environment variable is used unless Isolated or
+	// IgnoreEnvironment is set.
+	VirtualEnv string
+
+	executable string
+}
+
+// InitializeWithConfig initializes Python like Initialize with the options of
+// config and returns an error if Python fails to initialize. Python can't be
+// initialized again in the process after it failed during initialization,
+// for example because ModuleSearchPaths misses the standard library.
+func InitializeWithConfig(config Config) error {
+	if err := config.validate(); err != nil {
+		return err
+	}
+	if err := config.useVirtualEnv(); err != nil {
+		return err
+	}
+	isolated := C.int(0)
+	if config.Isolated {
+		isolated = 1
+	}
+	utf8Mode := C.int(0)
+	if config.UTF8Mode {
+		utf8Mode = 1
+	}
+	if err := statusError(C.preInitialize(isolated, utf8Mode)); err != nil {
+		return err
+	}
+
+	var cfg C.PyConfig
+	C.initConfig(&cfg, isolated)
+	defer C.PyConfig_Clear(&cfg)
+	if err := config.apply(&cfg); err != nil {
+		return err
+	}
+	runtime.LockOSThread()
+	if err := statusError(C.Py_InitializeFromConfig(&cfg)); err != nil {
+		runtime.UnlockOSThread()
+		return err
+	}
+	startPython()
+	return nil
+}
+
+func (config *Config) validate() error {
+	values := append([]string{config.Home, config.ProgramName, config.VirtualEnv}, config.Argv...)
+	for _, value := range append(values, config.ModuleSearchPaths...) {
+		if strings.IndexByte(value, 0) >= 0 {
+			return fmt.Errorf("gp: invalid config string %q: contains NUL", value)
+		}
+	}
+	return nil
+}
+
+// useVirtualEnv checks the virtual environment and makes Python discover it
+// from its interpreter path, as when running the venv's python.
+func (config *Config) useVirtualEnv() error {
+	dir := config.VirtualEnv
+	if dir == "" && !config.Isolated && !config.IgnoreEnvironment {
+		dir = os.Getenv("VIRTUAL_ENV")
+	}
+	if dir == "" {
+		return nil
+	}
+	if config.Home != "" {
+		return errors.New("gp: VirtualEnv and Home can't be both set")
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	cfg, err := readPyvenvCfg(dir)
+	if err != nil {
+		return fmt.Errorf("gp: %s is not a virtual environment: %w", dir, err)
+	}
+	version := cfg["version_info"]
+	if version == "" {
+		version = cfg["version"]
+	}
+	want := fmt.Sprintf("%d.%d", C.PY_MAJOR_VERSION, C.PY_MINOR_VERSION)
+	if version != want && !strings.HasPrefix(version, want+".") {
+		return fmt.Errorf("gp: virtual environment %s is for Python %s, not %s", dir, version, want)
+	}
+	if runtime.GOOS == "windows" {
+		config.executable = filepath.Join(dir, "Scripts", "python.exe")
+	} else {
+		config.executable = filepath.Join(dir, "bin", "python")
+	}
+	return nil
+}
+
+// readPyvenvCfg reads the key = value lines of the pyvenv.cfg file in dir.
+func readPyvenvCfg(dir string) (map[string]string, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "pyvenv.cfg"))
+	if err != nil {
+		return nil, err
+	}
+	cfg := make(map[string]string)
+	for _, line := range strings.Split(string(data), "\n") {
+		key, value, ok := strings.Cut(line, "=")
+		if ok {
+			cfg[strings.ToLower(strings.TrimSpace(key))] = strings.TrimSpace(value)
+		}
+	}
+	return cfg, nil
+}
+
+func (config *Config) apply(cfg *C.PyConfig) error {
+	if config.IgnoreEnvironment {
+		cfg.use_environment = 0
+	}
+	if config.NoSite {
+		cfg.site_import = 0
+	}
+	if config.UseHashSeed {
+		C.setHashSeed(cfg, C.ulong(config.HashSeed))
+	}
+	if config.Home != "" {
+		if err := setConfigString(cfg, &cfg.home, config.Home); err != nil {
+			return err
+		}
+	}
+	if config.executable != "" {
+		if err := setConfigString(cfg, &cfg.executable, config.executable); err != nil {
+			return err
+		}
+	}
+	if config.ProgramName != "" {
+		if err := setConfigString(cfg, &cfg.program_name, config.ProgramName); err != nil {
+			return err
+		}
+	}
+	if config.Argv != nil {
+		argv := make([]*C.char, len(config.Argv))
+		for i, arg := range config.Argv {
+			argv[i] = C.CString(arg)
+			defer C.free(unsafe.Pointer(argv[i]))
+		}
+		var p **C.char
+		if len(argv) > 0 {
+			p = &argv[0]
+		}
+		if err := statusError(C.PyConfig_SetBytesArgv(cfg, C.Py_ssize_t(len(argv)), p)); err != nil {
+			return err
+		}
+	}
+	if config.ModuleSearchPaths != nil {
+		C.setModuleSearchPathsSet(cfg)
+		for _, path := range config.ModuleSearchPaths {
+			cpath := C.CString(path)
+			err := statusError(C.appendModuleSearchPath(cfg, cpath))
+			C.free(unsafe.Pointer(cpath))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func setConfigString(cfg *C.PyConfig, field **C.wchar_t, value string) error {
+	cvalue := C.CString(value)
+	defer C.free(unsafe.Pointer(cvalue))
+	return statusError(C.setConfigString(cfg, field, cvalue))
+}
+
+// statusError converts a failed PyStatus to an error.
+func statusError(status C.PyStatus) error {
+	if C.PyStatus_Exception(status) == 0 {
+		return nil
+	}
+	if C.PyStatus_IsExit(status) != 0 {
+		return fmt.Errorf("gp: python exited with code %d during initialization", int(status.exitcode))
+	}
+	msg := "unknown error"
+	if status.err_msg != nil {
+		msg = C.GoString(status.err_msg)
+	}
+	if status._func != nil {
+		return fmt.Errorf("gp: initialize python: %s: %s", C.GoString(status._func), msg)
+	}
+	return fmt.Errorf("gp: initialize python: %s", msg)
+}
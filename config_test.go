@@ -0,0 +1,158 @@
+package gp
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestInitializeWithConfig(t *testing.T) {
+	testMutex.Lock()
+	defer testMutex.Unlock()
+
+	// Keep the standard library paths of a default interpreter.
+	Initialize()
+	var stdlib []string
+	paths := ImportModule("sys").Attr("path").AsList()
+	for i := 0; i < paths.Len(); i++ {
+		if p := paths.GetItem(i).String(); p != "" && !strings.Contains(p, "site-packages") {
+			stdlib = append(stdlib, p)
+		}
+	}
+	Finalize()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "cfgmod.py"), []byte("value = 42\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PYTHONPATH", "/from/env")
+	t.Setenv("PYTHONHASHSEED", "123")
+
+	err := InitializeWithConfig(Config{
+		Isolated:          true,
+		Argv:              []string{"prog", "-c", "--flag"},
+		ModuleSearchPaths: append([]string{dir}, stdlib...),
+		NoSite:            true,
+		UTF8Mode:          true,
+		UseHashSeed:       true,
+		HashSeed:          0,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Finalize()
+
+	code := `
+import sys, os
+assert sys.flags.isolated == 1, sys.flags
+assert sys.flags.ignore_environment == 1, sys.flags
+assert sys.flags.no_site == 1, sys.flags
+assert "site" not in sys.modules
+assert sys.flags.utf8_mode == 1, sys.flags
+assert sys.flags.hash_randomization == 0, sys.flags
+assert sys.argv == ["prog", "-c", "--flag"], sys.argv
+assert "/from/env" not in sys.path, sys.path
+import cfgmod
+assert cfgmod.value == 42
+`
+	if err := RunString(code); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestInitializeWithConfigError(t *testing.T) {
+	testMutex.Lock()
+	defer testMutex.Unlock()
+
+	err := InitializeWithConfig(Config{Argv: []string{"prog", "a\x00b"}})
+	if err == nil || !strings.Contains(err.Error(), "NUL") {
+		t.Errorf("InitializeWithConfig() error = %v, want NUL error", err)
+	}
+}
+
+// makeVirtualEnv creates a venv of the running Python version, with a module
+// in its site-packages, the way `python -m venv --without-pip` does.
+func makeVirtualEnv(t *testing.T, version string) (dir, basePrefix string) {
+	Initialize()
+	sys := ImportModule("sys")
+	basePrefix = sys.Attr("base_prefix").String()
+	info := sys.Attr("version_info").AsTuple()
+	major, minor := info.Get(0).AsLong().Int(), info.Get(1).AsLong().Int()
+	Finalize()
+	if version == "" {
+		version = fmt.Sprintf("%d.%d.0", major, minor)
+	}
+
+	dir = t.TempDir()
+	home := filepath.Join(basePrefix, "bin")
+	if runtime.GOOS == "windows" {
+		home = basePrefix
+	}
+	cfg := fmt.Sprintf("home = %s\ninclude-system-site-packages = false\nversion = %s\n", home, version)
+	if err := os.WriteFile(filepath.Join(dir, "pyvenv.cfg"), []byte(cfg), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sitePackages := filepath.Join(dir, "lib", fmt.Sprintf("python%d.%d", major, minor), "site-packages")
+	if runtime.GOOS == "windows" {
+		sitePackages = filepath.Join(dir, "Lib", "site-packages")
+	}
+	if err := os.MkdirAll(sitePackages, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(sitePackages, "venvmod.py"), []byte("value = 'venv'\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dir, basePrefix
+}
+
+func TestInitializeVirtualEnv(t *testing.T) {
+	testMutex.Lock()
+	defer testMutex.Unlock()
+
+	dir, basePrefix := makeVirtualEnv(t, "")
+	code := fmt.Sprintf(`
+import os, sys
+assert os.path.samefile(sys.prefix, %[1]q), sys.prefix
+assert os.path.samefile(sys.exec_prefix, %[1]q), sys.exec_prefix
+assert os.path.samefile(sys.base_prefix, %[2]q), sys.base_prefix
+import venvmod
+assert venvmod.value == "venv"
+assert os.path.realpath(venvmod.__file__).startswith(os.path.realpath(sys.prefix))
+`, dir, basePrefix)
+
+	t.Run("VirtualEnv", func(t *testing.T) {
+		if err := InitializeWithConfig(Config{VirtualEnv: dir}); err != nil {
+			t.Fatal(err)
+		}
+		defer Finalize()
+		if err := RunString(code); err != nil {
+			t.Error(err)
+		}
+	})
+	t.Run("VIRTUAL_ENV", func(t *testing.T) {
+		t.Setenv("VIRTUAL_ENV", dir)
+		if err := InitializeWithConfig(Config{}); err != nil {
+			t.Fatal(err)
+		}
+		defer Finalize()
+		if err := RunString(code); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestInitializeVirtualEnvError(t *testing.T) {
+	testMutex.Lock()
+	defer testMutex.Unlock()
+
+	dir, _ := makeVirtualEnv(t, "2.7.18")
+	if err := InitializeWithConfig(Config{VirtualEnv: dir}); err == nil || !strings.Contains(err.Error(), "2.7.18") {
+		t.Errorf("InitializeWithConfig() with a 2.7 venv = %v, want version error", err)
+	}
+	if err := InitializeWithConfig(Config{VirtualEnv: t.TempDir()}); err == nil || !strings.Contains(err.Error(), "not a virtual environment") {
+		t.Errorf("InitializeWithConfig() without pyvenv.cfg = %v, want error", err)
+	}
+}
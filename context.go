@@ -0,0 +1,110 @@
+package gp
+
+/*
+#include <Python.h>
+*/
+import "C"
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// ContextCancelledType returns the exception class raised in Python code run
+// by RunStringContext, EvalCodeContext or Func.CallContext when the context
+// is done. It derives from BaseException, so `except Exception` doesn't
+// catch it. Python code imports it with `from _gp import ContextCancelled`.
+func ContextCancelledType() Object {
+	return getGlobalData().cancelledType
+}
+
+// RunStringContext is like RunString but interrupts the code when ctx is
+// done. The returned error then wraps both ctx.Err() and the *PyError with
+// the traceback of where the code was interrupted.
+func RunStringContext(ctx context.Context, code string) error {
+	return runContext(ctx, func() error {
+		return RunString(code)
+	})
+}
+
+// EvalCodeContext evaluates a code object like EvalCode, interrupting it
+// when ctx is done as RunStringContext does.
+func EvalCodeContext(ctx context.Context, code Object, globals, locals Dict) (ret Object, err error) {
+	err = runContext(ctx, func() error {
+		ret, err = tryObject(C.PyEval_EvalCode(code.cpyObj(), globals.cpyObj(), locals.cpyObj()))
+		return err
+	})
+	return ret, err
+}
+
+// CallContext is like TryCall but interrupts the function when ctx is done
+// as RunStringContext does.
+func (f Func) CallContext(ctx context.Context, args ...any) (ret Object, err error) {
+	err = runContext(ctx, func() error {
+		ret, err = f.TryCall(args...)
+		return err
+	})
+	return ret, err
+}
+
+// runContext runs fn, which calls into Python on the current thread, and
+// raises ContextCancelledType in the thread when ctx is done. The exception
+// is only raised while the thread runs Python code: a Go function called
+// from Python that blocks with the GIL released is interrupted once it
+// returns. Only code running in the main interpreter can be interrupted.
+func runContext(ctx context.Context, fn func() error) error {
+	if ctx.Done() == nil {
+		return fn()
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ident := C.PyThread_get_thread_ident()
+	exc := getGlobalData().cancelledType
+	var (
+		mu       sync.Mutex
+		finished bool
+		injected bool
+	)
+	stop := make(chan struct{})
+	watcherDone := make(chan struct{})
+	go func() {
+		defer close(watcherDone)
+		select {
+		case <-ctx.Done():
+			WithGIL(func() {
+				mu.Lock()
+				defer mu.Unlock()
+				if !finished {
+					C.PyThreadState_SetAsyncExc(ident, exc.obj)
+					injected = true
+				}
+			})
+		case <-stop:
+		}
+	}()
+
+	err := fn()
+	mu.Lock()
+	finished = true
+	mu.Unlock()
+	close(stop)
+	// The watcher may be waiting for the GIL.
+	s := ReleaseGIL()
+	<-watcherDone
+	s.Restore()
+	if injected {
+		// Drop the exception if fn returned before it was raised.
+		C.PyThreadState_SetAsyncExc(ident, nil)
+	}
+
+	if err != nil && ctx.Err() != nil && ErrorMatches(err, exc) {
+		return fmt.Errorf("%w: %w", ctx.Err(), err)
+	}
+	return err
+}
@@ -0,0 +1,82 @@
+package gp
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunStringContext(t *testing.T) {
+	setupTest(t)
+	if err := RunString(`
+def spin():
+    while True:
+        pass
+
+def swallow():
+    while True:
+        try:
+            spin()
+        except Exception:
+            pass
+`); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := RunStringContext(ctx, "swallow()")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("RunStringContext() = %v, want DeadlineExceeded", err)
+	}
+	var pyErr *PyError
+	if !errors.As(err, &pyErr) || !pyErr.Matches(ContextCancelledType()) {
+		t.Fatalf("RunStringContext() = %v, want a ContextCancelled *PyError", err)
+	}
+	if f := pyErr.Frames[len(pyErr.Frames)-1]; f.Name != "spin" {
+		t.Errorf("innermost frame = %+v, want spin", f)
+	}
+	if !strings.Contains(err.Error(), "context deadline exceeded") {
+		t.Errorf("Error() = %q", err.Error())
+	}
+
+	// Code that finishes in time is unaffected, and a late cancellation
+	// doesn't leak into later calls.
+	ctx, cancel = context.WithCancel(context.Background())
+	if err := RunStringContext(ctx, "x = sum(range(10))"); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	if err := RunString("assert x == 45"); err != nil {
+		t.Fatal(err)
+	}
+	if err := RunStringContext(ctx, "pass"); !errors.Is(err, context.Canceled) {
+		t.Errorf("RunStringContext() with done context = %v, want Canceled", err)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+	_, err = MainModule().AttrFunc("spin").CallContext(ctx)
+	if !errors.Is(err, context.Canceled) || !ErrorMatches(err, ContextCancelledType()) {
+		t.Errorf("CallContext() = %v, want Canceled", err)
+	}
+	if ErrorMatches(err, Exception) {
+		t.Error("ContextCancelled should not derive from Exception")
+	}
+	if err := RunString("from _gp import ContextCancelled"); err != nil {
+		t.Fatal(err)
+	}
+	if !MainModule().Attr("ContextCancelled").Equals(ContextCancelledType()) {
+		t.Error("_gp.ContextCancelled is not ContextCancelledType()")
+	}
+
+	got, err := ImportModule("builtins").AttrFunc("abs").CallContext(context.Background(), -3)
+	if err != nil || got.AsLong().Int() != 3 {
+		t.Errorf("CallContext(abs, -3) = %v, %v", got, err)
+	}
+}
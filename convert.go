@@ -2,19 +2,36 @@ package gp
 
 /*
 #include <Python.h>
+
+static int isBool(PyObject *o) { return PyBool_Check(o); }
+static int isLong(PyObject *o) { return PyLong_Check(o) && !PyBool_Check(o); }
+static int isFloat(PyObject *o) { return PyFloat_Check(o); }
+static int isComplex(PyObject *o) { return PyComplex_Check(o); }
+static int isStr(PyObject *o) { return PyUnicode_Check(o); }
+static int isBytes(PyObject *o) { return PyBytes_Check(o); }
+static int isList(PyObject *o) { return PyList_Check(o); }
+static int isTuple(PyObject *o) { return PyTuple_Check(o); }
+static int isDict(PyObject *o) { return PyDict_Check(o); }
+static int isSet(PyObject *o) { return PySet_Check(o); }
+static int isFrozenSet(PyObject *o) { return PyFrozenSet_Check(o); }
+static int isAnySet(PyObject *o) { return PyAnySet_Check(o); }
+static int isModuleObj(PyObject *o) { return PyModule_Check(o); }
 */
 import "C"
 
 import (
+	"errors"
 	"fmt"
+	"math/big"
 	"reflect"
+	"time"
 	"unsafe"
 )
 
 func From(from any) Object {
 	switch v := from.(type) {
 	case Objecter:
-		return newObject(v.cpyObj())
+		return newObjectRef(v.cpyObj())
 	case int8:
 		return newObject(C.PyLong_FromLong(C.long(v)))
 	case int16:
@@ -58,6 +75,17 @@ func From(from any) Object {
 		return MakeComplex(complex128(v)).Object
 	case []byte:
 		return MakeBytes(v).Object
+	case *big.Int:
+		if v == nil {
+			return None()
+		}
+		return LongFromBigInt(v).Object
+	case big.Int:
+		return LongFromBigInt(&v).Object
+	case time.Time:
+		return MakeDateTime(v).Object
+	case time.Duration:
+		return MakeTimeDelta(v).Object
 	case bool:
 		if v {
 			return True().Object
@@ -71,8 +99,7 @@ func From(from any) Object {
 		switch vv.Kind() {
 		case reflect.Ptr:
 			if vv.Elem().Type().Kind() == reflect.Struct {
-				maps := getGlobalData()
-				if pyType, ok := maps.pyTypes[vv.Elem().Type()]; ok {
+				if pyType, ok := getGlobalData().pyType(vv.Elem().Type()); ok {
 					wrapper := allocWrapper((*C.PyTypeObject)(unsafe.Pointer(pyType)), vv.Interface())
 					return newObject((*C.PyObject)(unsafe.Pointer(wrapper)))
 				}
@@ -81,6 +108,9 @@ func From(from any) Object {
 		case reflect.Slice:
 			return fromSlice(vv).Object
 		case reflect.Map:
+			if vv.Type().Elem() == emptyStructType {
+				return fromSet(vv).Object
+			}
 			return fromMap(vv).Object
 		case reflect.Struct:
 			return fromStruct(vv)
@@ -91,135 +121,510 @@ func From(from any) Object {
 	}
 }
 
+var (
+	objectType      = reflect.TypeOf(Object{})
+	objecterType    = reflect.TypeOf((*Objecter)(nil)).Elem()
+	bigIntType      = reflect.TypeOf(big.Int{})
+	emptyStructType = reflect.TypeOf(struct{}{})
+)
+
+// ToValue converts from to the type of to and stores it in to, which must be
+// settable. It returns false if from doesn't match the type, and panics if
+// the type isn't supported. To reports why the conversion failed.
 func ToValue(from Object, to reflect.Value) bool {
 	if !to.IsValid() || !to.CanSet() {
 		panic(fmt.Errorf("value is not valid or cannot be set: %v\n", to))
 	}
+	err := toValue(from, to, rootPath)
+	var unsupported *unsupportedTypeError
+	if errors.As(err, &unsupported) {
+		panic(fmt.Errorf("unsupported type conversion from Python object to %v", unsupported.typ))
+	}
+	return err == nil
+}
 
-	switch to.Kind() {
-	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int:
-		if from.IsLong() {
-			to.SetInt(cast[Long](from).Int64())
-		} else {
-			return false
+// To converts a Python object to a Go value of type T like the arguments of
+// Go functions called from Python: ints, floats, complex numbers, strings,
+// bools and bytes convert to the matching Go kinds, ints also to big.Int,
+// lists and tuples to slices and arrays, dicts to maps and structs, sets to
+// map[K]struct{}, datetimes to time.Time, timedeltas to time.Duration, None
+// to nil pointers, and objects of types added with Module.AddType to their
+// Go value. Converting to an Objecter type checks the Python type as As
+// does, and converting to any gives the natural Go value of the object.
+//
+// If the object doesn't match T, the error is a *ConversionError locating
+// the mismatch, such as "field `items[3].price`: expected float, got str".
+func To[T any](from Object) (T, error) {
+	var to T
+	err := toValue(from, reflect.ValueOf(&to).Elem(), rootPath)
+	return to, err
+}
+
+// As returns from as the Objecter type T, such as Dict or List, after
+// checking that it is an instance of the matching Python type. Unlike
+// Object.AsDict and friends, which don't check the type, it returns a
+// *ConversionError on mismatch. T must be Object or an Objecter type of
+// this package; As fails for others, such as wrappers embedding Object, as
+// it doesn't know their Python type.
+func As[T Objecter](from Object) (T, error) {
+	return To[T](from)
+}
+
+// ConversionError is the error of To and As when a Python object doesn't
+// match the Go type.
+type ConversionError struct {
+	// Path locates the mismatching value in the converted object, such as
+	// "items[3].price". It is empty for the object itself.
+	Path string
+	// Expected is the Python type the Go type requires.
+	Expected string
+	// Got is the Python type of the mismatching value.
+	Got string
+}
+
+func (e *ConversionError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("expected %s, got %s", e.Expected, e.Got)
+	}
+	return fmt.Sprintf("field `%s`: expected %s, got %s", e.Path, e.Expected, e.Got)
+}
+
+type unsupportedTypeError struct {
+	path string
+	typ  reflect.Type
+}
+
+func (e *unsupportedTypeError) Error() string {
+	if e.path == "" {
+		return fmt.Sprintf("unsupported conversion to Go type %v", e.typ)
+	}
+	return fmt.Sprintf("field `%s`: unsupported conversion to Go type %v", e.path, e.typ)
+}
+
+// valuePath builds the ConversionError.Path of a value. It is only called
+// when the conversion fails, so successful conversions don't format paths.
+type valuePath func() string
+
+func rootPath() string { return "" }
+
+func mismatch(from Object, path valuePath, expected string) error {
+	got := "NULL"
+	if !from.Nil() {
+		got = typeName(from.obj)
+	}
+	return &ConversionError{Path: path(), Expected: expected, Got: got}
+}
+
+func fieldPath(path valuePath, name string) valuePath {
+	return func() string {
+		if p := path(); p != "" {
+			return p + "." + name
+		}
+		return name
+	}
+}
+
+// objecterTypes are the Objecter types that To checks the Python type of.
+var objecterTypes = map[reflect.Type]struct {
+	name  string
+	check func(*C.PyObject) C.int
+}{
+	reflect.TypeOf(Bool{}):      {"bool", func(o *C.PyObject) C.int { return C.isBool(o) }},
+	reflect.TypeOf(Long{}):      {"int", func(o *C.PyObject) C.int { return C.isLong(o) }},
+	reflect.TypeOf(Float{}):     {"float", func(o *C.PyObject) C.int { return C.isFloat(o) }},
+	reflect.TypeOf(Complex{}):   {"complex", func(o *C.PyObject) C.int { return C.isComplex(o) }},
+	reflect.TypeOf(Str{}):       {"str", func(o *C.PyObject) C.int { return C.isStr(o) }},
+	reflect.TypeOf(Bytes{}):     {"bytes", func(o *C.PyObject) C.int { return C.isBytes(o) }},
+	reflect.TypeOf(List{}):      {"list", func(o *C.PyObject) C.int { return C.isList(o) }},
+	reflect.TypeOf(Tuple{}):     {"tuple", func(o *C.PyObject) C.int { return C.isTuple(o) }},
+	reflect.TypeOf(Dict{}):      {"dict", func(o *C.PyObject) C.int { return C.isDict(o) }},
+	reflect.TypeOf(Set{}):       {"set", func(o *C.PyObject) C.int { return C.isSet(o) }},
+	reflect.TypeOf(FrozenSet{}): {"frozenset", func(o *C.PyObject) C.int { return C.isFrozenSet(o) }},
+	reflect.TypeOf(Module{}):    {"module", func(o *C.PyObject) C.int { return C.isModuleObj(o) }},
+	reflect.TypeOf(Func{}):      {"callable", func(o *C.PyObject) C.int { return C.PyCallable_Check(o) }},
+	reflect.TypeOf(DateTime{}):  {"datetime", isDateTime},
+	reflect.TypeOf(Date{}):      {"date", isDate},
+	reflect.TypeOf(TimeOfDay{}): {"time", isTimeOfDay},
+	reflect.TypeOf(TimeDelta{}): {"timedelta", isTimeDelta},
+}
+
+// isValueType reports whether the struct type t converts to a Python value
+// of its own rather than to a dict or a type added with AddType.
+func isValueType(t reflect.Type) bool {
+	return t == timeType || t == bigIntType
+}
+
+func toValue(from Object, to reflect.Value, path valuePath) error {
+	t := to.Type()
+	if from.Nil() {
+		return mismatch(from, path, "object")
+	}
+	if t == objectType {
+		to.Set(reflect.ValueOf(from))
+		return nil
+	}
+	obj := from.obj
+	switch t {
+	case bigIntType:
+		if C.isLong(obj) == 0 {
+			return mismatch(from, path, "int")
 		}
-	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint:
-		if from.IsLong() {
-			to.SetUint(cast[Long](from).Uint64())
+		to.Set(reflect.ValueOf(cast[Long](from).BigInt()).Elem())
+		return nil
+	case timeType:
+		if isDateTime(obj) != 0 {
+			to.Set(reflect.ValueOf(cast[DateTime](from).Time()))
+		} else if isDate(obj) != 0 {
+			to.Set(reflect.ValueOf(cast[Date](from).Time()))
 		} else {
-			return false
+			return mismatch(from, path, "datetime")
+		}
+		return nil
+	case durationType:
+		if isTimeDelta(obj) == 0 {
+			return mismatch(from, path, "timedelta")
+		}
+		d, ok := cast[TimeDelta](from).duration()
+		if !ok {
+			return mismatch(from, path, "timedelta in the range of time.Duration")
 		}
+		to.SetInt(int64(d))
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int:
+		if C.isLong(obj) == 0 {
+			return mismatch(from, path, "int")
+		}
+		var overflow C.int
+		v := int64(C.PyLong_AsLongLongAndOverflow(obj, &overflow))
+		if overflow != 0 || to.OverflowInt(v) {
+			return mismatch(from, path, fmt.Sprintf("int in the range of %v", t))
+		}
+		to.SetInt(v)
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint, reflect.Uintptr:
+		if C.isLong(obj) == 0 {
+			return mismatch(from, path, "int")
+		}
+		v := uint64(C.PyLong_AsUnsignedLongLong(obj))
+		if C.PyErr_Occurred() != nil || to.OverflowUint(v) {
+			C.PyErr_Clear()
+			return mismatch(from, path, fmt.Sprintf("int in the range of %v", t))
+		}
+		to.SetUint(v)
 	case reflect.Float32, reflect.Float64:
-		if from.IsFloat() || from.IsLong() {
-			to.SetFloat(cast[Float](from).Float64())
-		} else {
-			return false
+		if C.isFloat(obj) == 0 && C.isLong(obj) == 0 {
+			return mismatch(from, path, "float")
+		}
+		v := float64(C.PyFloat_AsDouble(obj))
+		if C.PyErr_Occurred() != nil || to.OverflowFloat(v) {
+			C.PyErr_Clear()
+			return mismatch(from, path, fmt.Sprintf("float in the range of %v", t))
 		}
+		to.SetFloat(v)
 	case reflect.Complex64, reflect.Complex128:
-		if from.IsComplex() {
-			to.SetComplex(cast[Complex](from).Complex128())
-		} else {
-			return false
+		if C.isComplex(obj) == 0 && C.isFloat(obj) == 0 && C.isLong(obj) == 0 {
+			return mismatch(from, path, "complex")
 		}
+		v := complex(float64(C.PyComplex_RealAsDouble(obj)), float64(C.PyComplex_ImagAsDouble(obj)))
+		if C.PyErr_Occurred() != nil {
+			C.PyErr_Clear()
+			return mismatch(from, path, fmt.Sprintf("complex in the range of %v", t))
+		}
+		to.SetComplex(v)
 	case reflect.String:
-		if from.IsStr() {
-			to.SetString(cast[Str](from).String())
-		} else {
-			return false
+		if C.isStr(obj) == 0 {
+			return mismatch(from, path, "str")
 		}
+		to.SetString(cast[Str](from).String())
 	case reflect.Bool:
-		if from.IsBool() {
-			to.SetBool(cast[Bool](from).Bool())
-		} else {
-			return false
+		if C.isBool(obj) == 0 {
+			return mismatch(from, path, "bool")
 		}
+		to.SetBool(C.PyObject_IsTrue(obj) == 1)
 	case reflect.Slice:
-		if to.Type().Elem().Kind() == reflect.Uint8 { // []byte
-			if from.IsBytes() {
-				to.SetBytes(cast[Bytes](from).Bytes())
-			} else {
+		if t.Elem().Kind() == reflect.Uint8 { // []byte
+			if C.isBytes(obj) == 0 {
+				return mismatch(from, path, "bytes")
+			}
+			to.SetBytes(cast[Bytes](from).Bytes())
+			return nil
+		}
+		if C.isList(obj) == 0 && C.isTuple(obj) == 0 {
+			return mismatch(from, path, "list")
+		}
+		n := int(C.PySequence_Size(obj))
+		slice := reflect.MakeSlice(t, n, n)
+		if err := toElems(from, slice, path); err != nil {
+			return err
+		}
+		to.Set(slice)
+	case reflect.Array:
+		if C.isList(obj) == 0 && C.isTuple(obj) == 0 {
+			return mismatch(from, path, "list")
+		}
+		if n := int(C.PySequence_Size(obj)); n != t.Len() {
+			return mismatch(from, path, fmt.Sprintf("list of length %d", t.Len()))
+		}
+		array := reflect.New(t).Elem()
+		if err := toElems(from, array, path); err != nil {
+			return err
+		}
+		to.Set(array)
+	case reflect.Map:
+		if t.Elem() == emptyStructType {
+			return toSet(from, to, path)
+		}
+		if C.isDict(obj) == 0 {
+			return mismatch(from, path, "dict")
+		}
+		m := reflect.MakeMapWithSize(t, int(C.PyDict_Size(obj)))
+		var err error
+		cast[Dict](from).Items()(func(key, value Object) bool {
+			itemPath := func() string { return fmt.Sprintf("%s[%s]", path(), key.Repr()) }
+			var vk reflect.Value
+			if vk, err = toKey(key, t.Key(), itemPath); err != nil {
 				return false
 			}
-		} else {
-			if from.IsList() {
-				list := cast[List](from)
-				l := list.Len()
-				slice := reflect.MakeSlice(to.Type(), l, l)
-				for i := 0; i < l; i++ {
-					item := list.GetItem(i)
-					ToValue(item, slice.Index(i))
-				}
-				to.Set(slice)
-			} else {
+			vv := reflect.New(t.Elem()).Elem()
+			if err = toValue(value, vv, itemPath); err != nil {
 				return false
 			}
-		}
-	case reflect.Map:
-		if from.IsDict() {
-			t := to.Type()
-			to.Set(reflect.MakeMap(t))
-			dict := cast[Dict](from)
-			dict.Items()(func(key, value Object) bool {
-				vk := reflect.New(t.Key()).Elem()
-				vv := reflect.New(t.Elem()).Elem()
-				if !ToValue(key, vk) || !ToValue(value, vv) {
-					return false
-				}
-				to.SetMapIndex(vk, vv)
-				return true
-			})
+			m.SetMapIndex(vk, vv)
 			return true
-		} else {
-			return false
+		})
+		if err != nil {
+			return err
+		}
+		to.Set(m)
+	case reflect.Ptr:
+		if from.IsNone() {
+			to.Set(reflect.Zero(t))
+			return nil
+		}
+		if goObj, ok := goObject(from); ok && reflect.TypeOf(goObj) == t {
+			to.Set(reflect.ValueOf(goObj))
+			return nil
+		}
+		ptr := reflect.New(t.Elem())
+		if err := toValue(from, ptr.Elem(), path); err != nil {
+			return err
+		}
+		to.Set(ptr)
+	case reflect.Interface:
+		if goObj, ok := goObject(from); ok && reflect.TypeOf(goObj).Implements(t) {
+			to.Set(reflect.ValueOf(goObj))
+			return nil
+		}
+		if t.NumMethod() != 0 {
+			return &unsupportedTypeError{path(), t}
+		}
+		v, err := toAny(from, path)
+		if err != nil {
+			return err
+		}
+		if v != nil {
+			to.Set(reflect.ValueOf(v))
 		}
 	case reflect.Struct:
-		if from.IsDict() {
-			dict := cast[Dict](from)
-			t := to.Type()
-			for i := 0; i < t.NumField(); i++ {
-				field := t.Field(i)
-				key := goNameToPythonName(field.Name)
-				if !dict.HasKey(MakeStr(key)) {
-					continue
-				}
-				value := dict.Get(MakeStr(key))
-				if !ToValue(value, to.Field(i)) {
-					SetTypeError(fmt.Errorf("failed to convert value to %v", field.Name))
-					return false
-				}
+		if objecter, ok := objecterTypes[t]; ok {
+			if objecter.check(obj) == 0 {
+				return mismatch(from, path, objecter.name)
 			}
-		} else {
-			maps := getGlobalData()
-			tyMeta := maps.typeMetas[from.Type().cpyObj()]
-			if tyMeta == nil {
-				return false
+			to.Field(0).Set(reflect.ValueOf(from))
+			return nil
+		}
+		if goObj, ok := goObject(from); ok {
+			if reflect.TypeOf(goObj).Elem() != t {
+				return mismatch(from, path, t.Name())
+			}
+			to.Set(reflect.ValueOf(goObj).Elem())
+			return nil
+		}
+		if t.Implements(objecterType) {
+			// An Objecter type not in objecterTypes, whose Python type is
+			// unknown.
+			return &unsupportedTypeError{path(), t}
+		}
+		if C.isDict(obj) == 0 {
+			return mismatch(from, path, "dict")
+		}
+		dict := cast[Dict](from)
+		for _, f := range pyFields(t) {
+			key := MakeStr(f.name)
+			if !dict.HasKey(key) {
+				continue
+			}
+			if err := toValue(dict.Get(key), fieldByIndex(to, f.index, true), fieldPath(path, f.name)); err != nil {
+				return err
 			}
-			wrapper := (*wrapperType)(unsafe.Pointer(from.cpyObj()))
-			to.Set(reflect.ValueOf(wrapper.goObj).Elem())
-			return true
 		}
 	default:
-		panic(fmt.Errorf("unsupported type conversion from Python object to %v", to.Type()))
+		return &unsupportedTypeError{path(), t}
+	}
+	return nil
+}
+
+// toSet converts the items of the set or frozenset from to the keys of the
+// map[K]struct{} to.
+func toSet(from Object, to reflect.Value, path valuePath) error {
+	if C.isAnySet(from.obj) == 0 {
+		return mismatch(from, path, "set")
+	}
+	t := to.Type()
+	m := reflect.MakeMapWithSize(t, int(C.PySet_Size(from.obj)))
+	var err error
+	setItems(from)(func(item Object) bool {
+		var vk reflect.Value
+		itemPath := func() string { return path() + "{" + item.Repr() + "}" }
+		if vk, err = toKey(item, t.Key(), itemPath); err != nil {
+			return false
+		}
+		m.SetMapIndex(vk, reflect.ValueOf(struct{}{}))
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	to.Set(m)
+	return nil
+}
+
+// toKey converts the dict key or set item from to a map key of type t. Keys
+// that convert to unhashable Go values, like tuples to []any, stay Objects
+// if t is an interface.
+func toKey(from Object, t reflect.Type, path valuePath) (reflect.Value, error) {
+	vk := reflect.New(t).Elem()
+	if err := toValue(from, vk, path); err != nil {
+		return vk, err
+	}
+	if hashable(vk) {
+		return vk, nil
+	}
+	if t.Kind() == reflect.Interface {
+		vk.Set(reflect.ValueOf(from))
+		return vk, nil
+	}
+	return vk, mismatch(from, path, "hashable "+t.String())
+}
+
+// hashable reports whether v can be a map key without panicking.
+func hashable(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Interface:
+		return v.IsNil() || hashable(v.Elem())
+	case reflect.Slice, reflect.Map, reflect.Func:
+		return false
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if !hashable(v.Index(i)) {
+				return false
+			}
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if !hashable(v.Field(i)) {
+				return false
+			}
+		}
 	}
 	return true
 }
 
+// toElems converts the items of the list or tuple from to the elements of
+// the slice or array to.
+func toElems(from Object, to reflect.Value, path valuePath) error {
+	for i := 0; i < to.Len(); i++ {
+		item := newObject(C.PySequence_GetItem(from.obj, C.Py_ssize_t(i)))
+		itemPath := func() string { return fmt.Sprintf("%s[%d]", path(), i) }
+		if err := toValue(item, to.Index(i), itemPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// toAny converts from to its natural Go value: nil, bool, int (int64 if it
+// doesn't fit an int, *big.Int if it doesn't fit an int64), float64,
+// complex128, string, []byte, []any, map[any]any, map[any]struct{} for sets,
+// time.Time for datetimes and dates, time.Duration for timedeltas, the Go
+// value of a type added with AddType or else the Object. Dict keys and set
+// items that would convert to unhashable Go values, like tuples, stay
+// Objects.
+func toAny(from Object, path valuePath) (any, error) {
+	obj := from.obj
+	switch {
+	case from.IsNone():
+		return nil, nil
+	case C.isBool(obj) != 0:
+		return C.PyObject_IsTrue(obj) == 1, nil
+	case C.isLong(obj) != 0:
+		v, err := To[int64](from)
+		if err != nil {
+			return cast[Long](from).BigInt(), nil
+		}
+		if int64(int(v)) == v {
+			return int(v), nil
+		}
+		return v, nil
+	case C.isFloat(obj) != 0:
+		return To[float64](from)
+	case C.isComplex(obj) != 0:
+		return To[complex128](from)
+	case C.isStr(obj) != 0:
+		return cast[Str](from).String(), nil
+	case C.isBytes(obj) != 0:
+		return cast[Bytes](from).Bytes(), nil
+	case C.isList(obj) != 0 || C.isTuple(obj) != 0:
+		items := make([]any, int(C.PySequence_Size(obj)))
+		err := toElems(from, reflect.ValueOf(items), path)
+		return items, err
+	case C.isDict(obj) != 0:
+		m := make(map[any]any)
+		err := toValue(from, reflect.ValueOf(&m).Elem(), path)
+		return m, err
+	case C.isAnySet(obj) != 0:
+		m := make(map[any]struct{})
+		err := toSet(from, reflect.ValueOf(&m).Elem(), path)
+		return m, err
+	case isDate(obj) != 0:
+		return To[time.Time](from)
+	case isTimeDelta(obj) != 0:
+		if d, ok := cast[TimeDelta](from).duration(); ok {
+			return d, nil
+		}
+		return from, nil
+	}
+	if goObj, ok := goObject(from); ok {
+		return goObj, nil
+	}
+	return from, nil
+}
+
+// goObject returns the pointer to the Go value wrapped by an object of a type
+// added with AddType.
+func goObject(from Object) (any, bool) {
+	if getGlobalData().typeMeta(from.Type().cpyObj()) == nil {
+		return nil, false
+	}
+	return (*wrapperType)(unsafe.Pointer(from.obj)).goObj, true
+}
+
 func fromSlice(v reflect.Value) List {
 	l := v.Len()
 	list := newList(C.PyList_New(C.Py_ssize_t(l)))
 	ty := v.Type().Elem()
-	maps := getGlobalData()
-	pyType, ok := maps.pyTypes[ty]
+	pyType, ok := getGlobalData().pyType(ty)
 	if !ok {
 		for i := 0; i < l; i++ {
-			C.PyList_SetItem(list.obj, C.Py_ssize_t(i), From(v.Index(i).Interface()).obj)
+			C.PyList_SetItem(list.obj, C.Py_ssize_t(i), From(v.Index(i).Interface()).newRef())
 		}
 	} else {
 		for i := 0; i < l; i++ {
 			elem := v.Index(i)
 			elemAddr := elem.Addr()
-			wrapper := allocWrapper((*C.PyTypeObject)(unsafe.Pointer(pyType)), 0)
-			wrapper.goObj = elemAddr.Interface()
+			wrapper := allocWrapper((*C.PyTypeObject)(unsafe.Pointer(pyType)), elemAddr.Interface())
 			C.PyList_SetItem(list.obj, C.Py_ssize_t(i), (*C.PyObject)(unsafe.Pointer(wrapper)))
 		}
 	}
@@ -237,18 +642,22 @@ func fromMap(v reflect.Value) Dict {
 
 func fromStruct(v reflect.Value) Object {
 	ty := v.Type()
-	maps := getGlobalData()
-	if typeObj, ok := maps.pyTypes[ty]; ok {
+	if typeObj, ok := getGlobalData().pyType(ty); ok {
 		ptr := reflect.New(ty)
 		ptr.Elem().Set(v)
 		wrapper := allocWrapper((*C.PyTypeObject)(unsafe.Pointer(typeObj)), ptr.Interface())
 		return newObject((*C.PyObject)(unsafe.Pointer(wrapper)))
 	}
 	dict := newDict(C.PyDict_New())
-	for i := 0; i < ty.NumField(); i++ {
-		field := ty.Field(i)
-		key := goNameToPythonName(field.Name)
-		dict.Set(MakeStr(key).Object, From(v.Field(i).Interface()))
+	for _, f := range pyFields(ty) {
+		fv := fieldByIndex(v, f.index, false)
+		if !fv.IsValid() {
+			continue
+		}
+		if f.omitEmpty && fv.IsZero() {
+			continue
+		}
+		dict.Set(MakeStr(f.name).Object, From(fv.Interface()))
 	}
 	return dict.Object
 }
@@ -1,6 +1,7 @@
 package gp
 
 import (
+	"errors"
 	"reflect"
 	"testing"
 )
@@ -379,3 +380,162 @@ func TestFromWithCustomType(t *testing.T) {
 		}
 	}()
 }
+
+type toItem struct {
+	Name  string
+	Price float64
+	Tags  []string
+}
+
+type toOrder struct {
+	ID    int
+	Items []toItem
+	Notes map[string]int
+	Ref   *toItem
+}
+
+func TestTo(t *testing.T) {
+	setupTest(t)
+	order, err := To[toOrder](evalString(t, `{"id": 7, "items": [{"name": "a", "price": 1.5, "tags": ["x"]}, {"name": "b", "price": 2}], "notes": {"n": 1}, "ref": None}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := toOrder{ID: 7, Items: []toItem{{"a", 1.5, []string{"x"}}, {"b", 2, nil}}, Notes: map[string]int{"n": 1}}
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("To[toOrder]() = %+v, want %+v", order, want)
+	}
+
+	if arr, err := To[[2]int](evalString(t, "(1, 2)")); err != nil || arr != [2]int{1, 2} {
+		t.Errorf("To[[2]int]() = %v, %v", arr, err)
+	}
+	if p, err := To[*int](evalString(t, "5")); err != nil || *p != 5 {
+		t.Errorf("To[*int]() = %v, %v", p, err)
+	}
+	v, err := To[any](evalString(t, `{"a": [1, 2.5, "s", None, True], "b": b"x"}`))
+	wantAny := map[any]any{"a": []any{1, 2.5, "s", nil, true}, "b": []byte("x")}
+	if err != nil || !reflect.DeepEqual(v, wantAny) {
+		t.Errorf("To[any]() = %#v, %v", v, err)
+	}
+	v, err = To[any](evalString(t, `{(1, 2): 3, "k": 4}`))
+	if m, ok := v.(map[any]any); err != nil || !ok || len(m) != 2 || m["k"] != 4 {
+		t.Errorf("To[any](tuple keys) = %#v, %v", v, err)
+	} else {
+		for k, v := range m {
+			if key, ok := k.(Object); k != "k" && (!ok || !key.IsTuple() || key.String() != "(1, 2)" || v != 3) {
+				t.Errorf("To[any](tuple keys) has %#v: %v", k, v)
+			}
+		}
+	}
+	if _, err := To[map[[2]int]int](evalString(t, `{(1, 2): 3}`)); err != nil {
+		t.Errorf("To[map[[2]int]int]() error = %v", err)
+	}
+
+	errTests := []struct {
+		name string
+		conv func(Object) error
+		code string
+		want string
+	}{
+		{"nested field", func(o Object) error { _, err := To[toOrder](o); return err },
+			`{"items": [{}, {}, {}, {"price": "9.99"}]}`, "field `items[3].price`: expected float, got str"},
+		{"map value", func(o Object) error { _, err := To[toOrder](o); return err },
+			`{"notes": {"k": [1]}}`, "field `notes['k']`: expected int, got list"},
+		{"top level", func(o Object) error { _, err := To[string](o); return err },
+			`1`, "expected str, got int"},
+		{"overflow", func(o Object) error { _, err := To[int8](o); return err },
+			`300`, "expected int in the range of int8, got int"},
+		{"float32 overflow", func(o Object) error { _, err := To[float32](o); return err },
+			`1e39`, "expected float in the range of float32, got float"},
+		{"negative uint", func(o Object) error { _, err := To[uint](o); return err },
+			`-1`, "expected int in the range of uint, got int"},
+		{"bool is not int", func(o Object) error { _, err := To[int](o); return err },
+			`True`, "expected int, got bool"},
+		{"array length", func(o Object) error { _, err := To[[2]int](o); return err },
+			`[1, 2, 3]`, "expected list of length 2, got list"},
+		{"unsupported", func(o Object) error { _, err := To[chan int](o); return err },
+			`1`, "unsupported conversion to Go type chan int"},
+	}
+	for _, tt := range errTests {
+		err := tt.conv(evalString(t, tt.code))
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("%s: error = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+
+	_, err = To[toOrder](evalString(t, `{"id": "x"}`))
+	var convErr *ConversionError
+	if !errors.As(err, &convErr) || convErr.Path != "id" || convErr.Expected != "int" || convErr.Got != "str" {
+		t.Errorf("To[toOrder]() error = %#v", err)
+	}
+}
+
+type taggedUser struct {
+	UserID   int    `py:"uid"`
+	Name     string `json:"full_name"`
+	Email    string `json:"email,omitempty"`
+	Password string `py:"-"`
+	Internal string `json:"-"`
+	HTTPURL  string
+	Nickname string `py:",omitempty" json:"nick"`
+	hidden   int
+}
+
+func TestStructTags(t *testing.T) {
+	setupTest(t)
+	user := taggedUser{UserID: 1, Name: "Ann", Password: "secret", Internal: "x", HTTPURL: "http://a", hidden: 2}
+	dict := From(user).AsDict()
+	want := map[string]any{"uid": 1, "full_name": "Ann", "http_url": "http://a"}
+	n := 0
+	dict.Items()(func(_, _ Object) bool { n++; return true })
+	if n != len(want) {
+		t.Errorf("From(taggedUser) = %v, want %v", dict, want)
+	}
+	for key, value := range want {
+		if got := dict.Get(MakeStr(key)); got.Nil() || !got.Equals(From(value)) {
+			t.Errorf("From(taggedUser)[%q] = %v, want %v", key, got, value)
+		}
+	}
+
+	dict.Set(MakeStr("email"), MakeStr("ann@example.com"))
+	dict.Set(MakeStr("nickname"), MakeStr("an"))
+	dict.Set(MakeStr("password"), MakeStr("ignored"))
+	got, err := To[taggedUser](dict.Object)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantUser := taggedUser{UserID: 1, Name: "Ann", Email: "ann@example.com", HTTPURL: "http://a", Nickname: "an"}
+	if got != wantUser {
+		t.Errorf("To[taggedUser]() = %+v, want %+v", got, wantUser)
+	}
+}
+
+func TestAs(t *testing.T) {
+	setupTest(t)
+	dict, err := As[Dict](From(map[string]int{"a": 1}))
+	if err != nil || dict.Get(MakeStr("a")).AsLong().Int() != 1 {
+		t.Errorf("As[Dict]() = %v, %v", dict, err)
+	}
+	if _, err := As[Dict](From([]int{1})); err == nil || err.Error() != "expected dict, got list" {
+		t.Errorf("As[Dict](list) error = %v", err)
+	}
+	if _, err := As[Long](From(true)); err == nil {
+		t.Error("As[Long](bool) should fail")
+	}
+	if _, err := As[Func](ImportModule("builtins").Attr("len")); err != nil {
+		t.Errorf("As[Func](len) error = %v", err)
+	}
+	if _, err := As[Module](From(1)); err == nil || err.Error() != "expected module, got int" {
+		t.Errorf("As[Module](int) error = %v", err)
+	}
+	if o, err := As[Object](From(1)); err != nil || o.AsLong().Int() != 1 {
+		t.Errorf("As[Object]() = %v, %v", o, err)
+	}
+	if _, err := As[userWrapper](MakeDict(nil).Object); err == nil || err.Error() != "unsupported conversion to Go type gp.userWrapper" {
+		t.Errorf("As[userWrapper]() error = %v", err)
+	}
+}
+
+// userWrapper is an Objecter type unknown to As.
+type userWrapper struct {
+	Object
+}
@@ -0,0 +1,310 @@
+package gp
+
+/*
+#include <Python.h>
+#include <datetime.h>
+
+// The datetime C API is loaded per interpreter, see importDateTime, and
+// passed to these helpers instead of using the PyDateTimeAPI static, which
+// is shared by all interpreters of the process.
+
+static PyDateTime_CAPI *importDateTime(void) {
+	return (PyDateTime_CAPI *)PyCapsule_Import(PyDateTime_CAPSULE_NAME, 0);
+}
+
+static int isDateTimeObj(PyDateTime_CAPI *api, PyObject *o) { return PyObject_TypeCheck(o, api->DateTimeType); }
+static int isDateObj(PyDateTime_CAPI *api, PyObject *o) { return PyObject_TypeCheck(o, api->DateType); }
+static int isTimeObj(PyDateTime_CAPI *api, PyObject *o) { return PyObject_TypeCheck(o, api->TimeType); }
+static int isDeltaObj(PyDateTime_CAPI *api, PyObject *o) { return PyObject_TypeCheck(o, api->DeltaType); }
+
+static PyObject *newDateTime(PyDateTime_CAPI *api, int year, int month, int day, int hour, int minute, int second, int usecond, PyObject *tz) {
+	return api->DateTime_FromDateAndTime(year, month, day, hour, minute, second, usecond, tz, api->DateTimeType);
+}
+
+static PyObject *newDate(PyDateTime_CAPI *api, int year, int month, int day) {
+	return api->Date_FromDate(year, month, day, api->DateType);
+}
+
+static PyObject *newTime(PyDateTime_CAPI *api, int hour, int minute, int second, int usecond) {
+	return api->Time_FromTime(hour, minute, second, usecond, Py_None, api->TimeType);
+}
+
+static PyObject *newDelta(PyDateTime_CAPI *api, int days, int seconds, int useconds) {
+	return api->Delta_FromDelta(days, seconds, useconds, 1, api->DeltaType);
+}
+
+static PyObject *newTimeZone(PyDateTime_CAPI *api, PyObject *offset, PyObject *name) {
+	return api->TimeZone_FromTimeZone(offset, name);
+}
+
+static PyObject *utcTimeZone(PyDateTime_CAPI *api) {
+	return Py_NewRef(api->TimeZone_UTC);
+}
+
+static int dateYear(PyObject *o) { return PyDateTime_GET_YEAR(o); }
+static int dateMonth(PyObject *o) { return PyDateTime_GET_MONTH(o); }
+static int dateDay(PyObject *o) { return PyDateTime_GET_DAY(o); }
+static int dateTimeHour(PyObject *o) { return PyDateTime_DATE_GET_HOUR(o); }
+static int dateTimeMinute(PyObject *o) { return PyDateTime_DATE_GET_MINUTE(o); }
+static int dateTimeSecond(PyObject *o) { return PyDateTime_DATE_GET_SECOND(o); }
+static int dateTimeMicrosecond(PyObject *o) { return PyDateTime_DATE_GET_MICROSECOND(o); }
+static int timeHour(PyObject *o) { return PyDateTime_TIME_GET_HOUR(o); }
+static int timeMinute(PyObject *o) { return PyDateTime_TIME_GET_MINUTE(o); }
+static int timeSecond(PyObject *o) { return PyDateTime_TIME_GET_SECOND(o); }
+static int timeMicrosecond(PyObject *o) { return PyDateTime_TIME_GET_MICROSECOND(o); }
+static int deltaDays(PyObject *o) { return PyDateTime_DELTA_GET_DAYS(o); }
+static int deltaSeconds(PyObject *o) { return PyDateTime_DELTA_GET_SECONDS(o); }
+static int deltaMicroseconds(PyObject *o) { return PyDateTime_DELTA_GET_MICROSECONDS(o); }
+*/
+import "C"
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sync/atomic"
+	"time"
+	"unsafe"
+)
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// importDateTime loads the datetime C API of the current interpreter once
+// and returns it.
+func importDateTime() *C.PyDateTime_CAPI {
+	gd := getGlobalData()
+	if api := atomic.LoadPointer(&gd.dateTimeAPI); api != nil {
+		return (*C.PyDateTime_CAPI)(api)
+	}
+	api := C.importDateTime()
+	if api == nil {
+		panic(FetchError())
+	}
+	atomic.StorePointer(&gd.dateTimeAPI, unsafe.Pointer(api))
+	return api
+}
+
+// dateTimeAPI returns the datetime C API if the datetime module is loaded,
+// loading the API if needed, or nil. Objects can't be datetimes before, and
+// checking them doesn't import the module.
+func dateTimeAPI() *C.PyDateTime_CAPI {
+	gd := getGlobalData()
+	if api := atomic.LoadPointer(&gd.dateTimeAPI); api != nil {
+		return (*C.PyDateTime_CAPI)(api)
+	}
+	mod := C.PyImport_GetModule(gd.dateTimeName.obj)
+	if mod == nil {
+		C.PyErr_Clear()
+		return nil
+	}
+	C.Py_DecRef(mod)
+	return importDateTime()
+}
+
+func isDateTime(o *C.PyObject) C.int {
+	api := dateTimeAPI()
+	if api == nil {
+		return 0
+	}
+	return C.isDateTimeObj(api, o)
+}
+
+func isDate(o *C.PyObject) C.int {
+	api := dateTimeAPI()
+	if api == nil {
+		return 0
+	}
+	return C.isDateObj(api, o)
+}
+
+func isTimeOfDay(o *C.PyObject) C.int {
+	api := dateTimeAPI()
+	if api == nil {
+		return 0
+	}
+	return C.isTimeObj(api, o)
+}
+
+func isTimeDelta(o *C.PyObject) C.int {
+	api := dateTimeAPI()
+	if api == nil {
+		return 0
+	}
+	return C.isDeltaObj(api, o)
+}
+
+// DateTime represents a Python datetime.datetime object.
+//
+// Python 3.12 crashes when the datetime module is imported again after
+// Finalize, so programs using these types there should initialize Python
+// only once.
+type DateTime struct {
+	Object
+}
+
+// MakeDateTime returns an aware datetime with the wall clock, to the
+// microsecond, and the UTC offset of t. Its tzinfo is datetime.timezone.utc
+// for times in time.UTC and else a fixed offset timezone named after the
+// zone abbreviation of t. It panics if the year of t is outside 1 to 9999.
+func MakeDateTime(t time.Time) DateTime {
+	api := importDateTime()
+	tz := timeZone(t)
+	return DateTime{must(tryObject(C.newDateTime(api, C.int(t.Year()), C.int(t.Month()), C.int(t.Day()),
+		C.int(t.Hour()), C.int(t.Minute()), C.int(t.Second()), C.int(t.Nanosecond()/1000), tz.obj)))}
+}
+
+func timeZone(t time.Time) Object {
+	if t.Location() == time.UTC {
+		return newObject(C.utcTimeZone(importDateTime()))
+	}
+	name, offset := t.Zone()
+	delta := MakeTimeDelta(time.Duration(offset) * time.Second)
+	if name == "" {
+		return must(tryObject(C.newTimeZone(importDateTime(), delta.obj, nil)))
+	}
+	return must(tryObject(C.newTimeZone(importDateTime(), delta.obj, MakeStr(name).obj)))
+}
+
+// Time returns the time of d. An aware datetime is in a time.FixedZone with
+// its UTC offset, rounded to the second, and tzname(), or in time.UTC for
+// datetime.timezone.utc. A naive datetime is taken in time.Local, as Python
+// does.
+func (d DateTime) Time() time.Time {
+	o := d.obj
+	return time.Date(int(C.dateYear(o)), time.Month(C.dateMonth(o)), int(C.dateDay(o)),
+		int(C.dateTimeHour(o)), int(C.dateTimeMinute(o)), int(C.dateTimeSecond(o)),
+		int(C.dateTimeMicrosecond(o))*1000, d.location())
+}
+
+func (d DateTime) location() *time.Location {
+	offset := d.Call("utcoffset")
+	if offset.IsNone() {
+		return time.Local
+	}
+	seconds := int(cast[TimeDelta](offset).Duration() / time.Second)
+	name := d.Call("tzname")
+	if name.IsNone() {
+		return time.FixedZone("", seconds)
+	}
+	if seconds == 0 && name.String() == "UTC" {
+		return time.UTC
+	}
+	return time.FixedZone(name.String(), seconds)
+}
+
+// Date represents a Python datetime.date object.
+type Date struct {
+	Object
+}
+
+// MakeDate returns the date year-month-day. It panics if the date is
+// invalid.
+func MakeDate(year int, month time.Month, day int) Date {
+	api := importDateTime()
+	return Date{must(tryObject(C.newDate(api, C.int(year), C.int(month), C.int(day))))}
+}
+
+func (d Date) Year() int {
+	return int(C.dateYear(d.obj))
+}
+
+func (d Date) Month() time.Month {
+	return time.Month(C.dateMonth(d.obj))
+}
+
+func (d Date) Day() int {
+	return int(C.dateDay(d.obj))
+}
+
+// Time returns the midnight UTC starting the date.
+func (d Date) Time() time.Time {
+	return time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// TimeOfDay represents a Python datetime.time object, a time of day without
+// a date.
+type TimeOfDay struct {
+	Object
+}
+
+// MakeTimeOfDay returns the naive time of day d after midnight, to the
+// microsecond. It panics if d is negative or not less than 24 hours.
+func MakeTimeOfDay(d time.Duration) TimeOfDay {
+	api := importDateTime()
+	if d < 0 || d >= 24*time.Hour {
+		panic(fmt.Errorf("gp: time of day %v out of range", d))
+	}
+	return TimeOfDay{must(tryObject(C.newTime(api, C.int(d/time.Hour), C.int(d%time.Hour/time.Minute),
+		C.int(d%time.Minute/time.Second), C.int(d%time.Second/time.Microsecond))))}
+}
+
+func (t TimeOfDay) Hour() int {
+	return int(C.timeHour(t.obj))
+}
+
+func (t TimeOfDay) Minute() int {
+	return int(C.timeMinute(t.obj))
+}
+
+func (t TimeOfDay) Second() int {
+	return int(C.timeSecond(t.obj))
+}
+
+func (t TimeOfDay) Microsecond() int {
+	return int(C.timeMicrosecond(t.obj))
+}
+
+// Duration returns the time elapsed since midnight, ignoring tzinfo.
+func (t TimeOfDay) Duration() time.Duration {
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute +
+		time.Duration(t.Second())*time.Second + time.Duration(t.Microsecond())*time.Microsecond
+}
+
+// TimeDelta represents a Python datetime.timedelta object.
+type TimeDelta struct {
+	Object
+}
+
+// MakeTimeDelta returns the timedelta d, truncated to the microsecond.
+func MakeTimeDelta(d time.Duration) TimeDelta {
+	api := importDateTime()
+	us := d.Microseconds()
+	const usPerDay = int64(24 * time.Hour / time.Microsecond)
+	return TimeDelta{must(tryObject(C.newDelta(api, C.int(us/usPerDay), C.int(us%usPerDay/1e6), C.int(us%1e6))))}
+}
+
+// Duration returns the duration of t. Like time.Time.Sub, it returns the
+// maximum or minimum duration if t doesn't fit a time.Duration.
+func (t TimeDelta) Duration() time.Duration {
+	d, ok := t.duration()
+	if !ok {
+		if t.Days() < 0 {
+			return math.MinInt64
+		}
+		return math.MaxInt64
+	}
+	return d
+}
+
+func (t TimeDelta) duration() (time.Duration, bool) {
+	days := time.Duration(t.Days())
+	rest := time.Duration(C.deltaSeconds(t.obj))*time.Second + time.Duration(C.deltaMicroseconds(t.obj))*time.Microsecond
+	const maxDays = math.MaxInt64 / time.Duration(24*time.Hour)
+	if days > maxDays || days < -maxDays {
+		return 0, false
+	}
+	d := days*24*time.Hour + rest
+	if d < 0 && days >= 0 {
+		return 0, false
+	}
+	return d, true
+}
+
+// Days returns the days of t, which is negative for negative timedeltas as
+// Python normalizes the seconds and microseconds to be positive.
+func (t TimeDelta) Days() int {
+	return int(C.deltaDays(t.obj))
+}
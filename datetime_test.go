@@ -0,0 +1,188 @@
+package gp
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// Python 3.12 crashes when datetime is imported again after Finalize, so the
+// datetime tests share one interpreter.
+func TestDateTime(t *testing.T) {
+	setupTest(t)
+	testDateTime(t)
+	testDateAndTimeOfDay(t)
+	testTimeDelta(t)
+	testTimeFields(t)
+}
+
+func testDateTime(t *testing.T) {
+	main := MainModule()
+
+	utc := time.Date(2024, time.March, 5, 13, 4, 5, 123456789, time.UTC)
+	dt := MakeDateTime(utc)
+	main.AddObject("dt", dt.Object)
+	if err := RunString(`
+import datetime
+assert dt == datetime.datetime(2024, 3, 5, 13, 4, 5, 123456, tzinfo=datetime.timezone.utc), dt
+assert dt.tzinfo is datetime.timezone.utc
+`); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := dt.Time(), utc.Truncate(time.Microsecond); !got.Equal(want) || got.Location() != time.UTC {
+		t.Errorf("Time() = %v, want %v", got, want)
+	}
+
+	cet := time.FixedZone("CET", 3600)
+	local := time.Date(2024, time.March, 5, 14, 4, 5, 0, cet)
+	main.AddObject("dt", From(local))
+	if err := RunString(`
+assert dt.utcoffset() == datetime.timedelta(hours=1), dt.utcoffset()
+assert dt.tzname() == "CET", dt.tzname()
+assert dt.hour == 14
+`); err != nil {
+		t.Fatal(err)
+	}
+	got, err := To[time.Time](main.Dict().Get(MakeStr("dt")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name, offset := got.Zone(); !got.Equal(local) || name != "CET" || offset != 3600 || got.Hour() != 14 {
+		t.Errorf("To[time.Time]() = %v, want %v", got, local)
+	}
+
+	naive, err := To[time.Time](evalString(t, "datetime.datetime(2000, 1, 2, 3, 4, 5)"))
+	if err != nil || naive != time.Date(2000, 1, 2, 3, 4, 5, 0, time.Local) {
+		t.Errorf("To[time.Time](naive) = %v, %v", naive, err)
+	}
+	date, err := To[time.Time](evalString(t, "datetime.date(2000, 1, 2)"))
+	if err != nil || date != time.Date(2000, 1, 2, 0, 0, 0, 0, time.UTC) {
+		t.Errorf("To[time.Time](date) = %v, %v", date, err)
+	}
+	if _, err := To[time.Time](evalString(t, "'2000-01-02'")); err == nil || err.Error() != "expected datetime, got str" {
+		t.Errorf("To[time.Time](str) error = %v", err)
+	}
+	if v, err := To[any](evalString(t, "datetime.datetime(2000, 1, 2, tzinfo=datetime.timezone.utc)")); err != nil || v != time.Date(2000, 1, 2, 0, 0, 0, 0, time.UTC) {
+		t.Errorf("To[any](datetime) = %#v, %v", v, err)
+	}
+	if dt, err := As[DateTime](evalString(t, "datetime.datetime.now()")); err != nil || dt.Nil() {
+		t.Errorf("As[DateTime]() = %v, %v", dt, err)
+	}
+	if _, err := As[DateTime](evalString(t, "datetime.date.today()")); err == nil {
+		t.Error("As[DateTime](date) succeeded")
+	}
+}
+
+func testDateAndTimeOfDay(t *testing.T) {
+	main := MainModule()
+
+	date := MakeDate(2024, time.February, 29)
+	main.AddObject("d", date.Object)
+	tod := MakeTimeOfDay(13*time.Hour + 4*time.Minute + 5*time.Second + 6*time.Microsecond)
+	main.AddObject("tod", tod.Object)
+	if err := RunString(`
+import datetime
+assert d == datetime.date(2024, 2, 29), d
+assert tod == datetime.time(13, 4, 5, 6), tod
+`); err != nil {
+		t.Fatal(err)
+	}
+	if date.Year() != 2024 || date.Month() != time.February || date.Day() != 29 {
+		t.Errorf("date = %d-%d-%d", date.Year(), date.Month(), date.Day())
+	}
+	if got := tod.Duration(); got != 13*time.Hour+4*time.Minute+5*time.Second+6*time.Microsecond {
+		t.Errorf("TimeOfDay.Duration() = %v", got)
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("MakeDate(2023, 2, 29) didn't panic")
+			}
+		}()
+		MakeDate(2023, time.February, 29)
+	}()
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("MakeTimeOfDay(24h) didn't panic")
+			}
+		}()
+		MakeTimeOfDay(24 * time.Hour)
+	}()
+}
+
+func testTimeDelta(t *testing.T) {
+	main := MainModule()
+
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{90*time.Minute + 1500*time.Nanosecond, "datetime.timedelta(seconds=5400, microseconds=1)"},
+		{-time.Microsecond, "datetime.timedelta(microseconds=-1)"},
+		{-50 * time.Hour, "datetime.timedelta(hours=-50)"},
+		{math.MaxInt64 / 1000 * 1000, "datetime.timedelta(microseconds=9223372036854775)"},
+	}
+	for _, tt := range tests {
+		delta := MakeTimeDelta(tt.d)
+		main.AddObject("delta", delta.Object)
+		if err := RunString("import datetime\nassert delta == " + tt.want + ", delta"); err != nil {
+			t.Errorf("MakeTimeDelta(%v): %v", tt.d, err)
+		}
+		if got, want := delta.Duration(), tt.d.Truncate(time.Microsecond); got != want {
+			t.Errorf("MakeTimeDelta(%v).Duration() = %v, want %v", tt.d, got, want)
+		}
+	}
+
+	if got := cast[TimeDelta](evalString(t, "datetime.timedelta.max")).Duration(); got != math.MaxInt64 {
+		t.Errorf("timedelta.max Duration() = %v", got)
+	}
+	if got := cast[TimeDelta](evalString(t, "datetime.timedelta.min")).Duration(); got != math.MinInt64 {
+		t.Errorf("timedelta.min Duration() = %v", got)
+	}
+	if _, err := To[time.Duration](evalString(t, "datetime.timedelta(days=200000)")); err == nil ||
+		err.Error() != "expected timedelta in the range of time.Duration, got datetime.timedelta" {
+		t.Errorf("To[time.Duration](overflow) error = %v", err)
+	}
+	if _, err := To[time.Duration](evalString(t, "5")); err == nil || err.Error() != "expected timedelta, got int" {
+		t.Errorf("To[time.Duration](int) error = %v", err)
+	}
+	if v, err := To[any](evalString(t, "datetime.timedelta(seconds=3)")); err != nil || v != 3*time.Second {
+		t.Errorf("To[any](timedelta) = %#v, %v", v, err)
+	}
+}
+
+type scheduledJob struct {
+	Name    string
+	Start   time.Time
+	Every   time.Duration
+	LastRun *time.Time
+}
+
+func testTimeFields(t *testing.T) {
+	main := MainModule()
+	main.AddType(scheduledJob{}, nil, "ScheduledJob", "")
+
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	job := &scheduledJob{Name: "backup", Start: start, Every: time.Hour}
+	main.AddObject("job", From(job))
+	if err := RunString(`
+import datetime
+assert job.start == datetime.datetime(2024, 1, 1, 9, tzinfo=datetime.timezone.utc), job.start
+assert job.every == datetime.timedelta(hours=1), job.every
+assert job.last_run is None
+job.last_run = job.start + job.every
+job.every = datetime.timedelta(minutes=30)
+`); err != nil {
+		t.Fatal(err)
+	}
+	if job.Every != 30*time.Minute || job.LastRun == nil || !job.LastRun.Equal(start.Add(time.Hour)) {
+		t.Errorf("job = %+v", job)
+	}
+
+	dict := From(struct{ At time.Time }{start}).AsDict()
+	if at, err := To[time.Time](dict.Get(MakeStr("at"))); err != nil || !at.Equal(start) {
+		t.Errorf("From(struct).at = %v, %v", at, err)
+	}
+}
@@ -0,0 +1,166 @@
+package gp
+
+/*
+#include <Python.h>
+*/
+import "C"
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultDecRefBatchSize is the default DecRefPolicy.BatchSize.
+const DefaultDecRefBatchSize = 128
+
+// DecRefPolicy controls when the references of garbage collected Objects
+// are released. Finalizers run on their own goroutine without the GIL, so
+// they only queue the DecRef, which is applied later on a thread holding
+// the GIL.
+type DecRefPolicy struct {
+	// BatchSize is the number of queued DecRefs that are applied together
+	// the next time Go calls a Python function or looks up an attribute, a
+	// thread acquires or releases the GIL or a Go function called from
+	// Python returns. Values below 1 mean 1, which applies them as soon as
+	// possible.
+	BatchSize int
+	// FlushInterval, if positive, also applies the DecRefs queued in the
+	// main interpreter at this interval from a background goroutine, which
+	// acquires the GIL when there are any. Sub-interpreters apply theirs at
+	// the end of Interpreter.Run.
+	FlushInterval time.Duration
+	// FlushOnAcquire applies the queued DecRefs whenever AcquireGIL,
+	// WithGIL or ThreadState.Restore acquire the GIL.
+	FlushOnAcquire bool
+}
+
+var (
+	decRefBatchSize int32 = DefaultDecRefBatchSize
+	flushOnAcquire  int32
+
+	policyMu   sync.Mutex
+	policy     = DecRefPolicy{BatchSize: DefaultDecRefBatchSize}
+	stopTicker chan struct{}
+
+	// backgroundMu guards pythonRunning. Finalize waits for backgroundCalls,
+	// the calls into Python that goroutines began while it was running.
+	backgroundMu    sync.Mutex
+	pythonRunning   bool
+	backgroundCalls sync.WaitGroup
+)
+
+// SetDecRefPolicy sets the policy used by all interpreters and returns the
+// previous one. The policy stays in effect across Finalize and Initialize.
+func SetDecRefPolicy(p DecRefPolicy) (previous DecRefPolicy) {
+	policyMu.Lock()
+	defer policyMu.Unlock()
+	previous = policy
+	policy = p
+
+	batch := p.BatchSize
+	if batch < 1 {
+		batch = 1
+	}
+	atomic.StoreInt32(&decRefBatchSize, int32(batch))
+	var onAcquire int32
+	if p.FlushOnAcquire {
+		onAcquire = 1
+	}
+	atomic.StoreInt32(&flushOnAcquire, onAcquire)
+
+	if stopTicker != nil {
+		close(stopTicker)
+		stopTicker = nil
+	}
+	if p.FlushInterval > 0 {
+		stopTicker = make(chan struct{})
+		go flushTicker(p.FlushInterval, stopTicker)
+	}
+	return previous
+}
+
+// GetDecRefPolicy returns the current policy.
+func GetDecRefPolicy() DecRefPolicy {
+	policyMu.Lock()
+	defer policyMu.Unlock()
+	return policy
+}
+
+func flushTicker(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			tickerFlush()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// tickerFlush applies the DecRefs queued in the main interpreter, if any.
+func tickerFlush() {
+	withGILInBackground(func() {
+		global.decRefList.decRefAll()
+	}, func() bool {
+		return global.decRefList.len() > 0
+	})
+}
+
+// withGILInBackground runs fn with the GIL held on a goroutine that doesn't
+// otherwise use Python, unless Python isn't running or cond, called first
+// under backgroundMu, returns false. Finalize waits for fn to return.
+func withGILInBackground(fn func(), cond func() bool) {
+	backgroundMu.Lock()
+	if !pythonRunning || (cond != nil && !cond()) {
+		backgroundMu.Unlock()
+		return
+	}
+	backgroundCalls.Add(1)
+	backgroundMu.Unlock()
+	defer backgroundCalls.Done()
+	WithGIL(fn)
+}
+
+func setPythonRunning(running bool) {
+	backgroundMu.Lock()
+	pythonRunning = running
+	backgroundMu.Unlock()
+	if !running {
+		// A background call may be waiting for the GIL. Python is about to
+		// be finalized, so the queued DecRefs are left alone.
+		ts := C.PyEval_SaveThread()
+		backgroundCalls.Wait()
+		C.PyEval_RestoreThread(ts)
+	}
+}
+
+// FlushDecRefs applies the queued DecRefs of garbage collected Objects right
+// away. The calling thread must hold the GIL.
+func FlushDecRefs() {
+	getGlobalData().decRefList.decRefAll()
+}
+
+// flushIfOnAcquire applies the queued DecRefs after acquiring the GIL: all
+// of them if the policy asks for it, else a full batch.
+func flushIfOnAcquire() {
+	gd := getGlobalData()
+	if gd == nil {
+		return
+	}
+	if atomic.LoadInt32(&flushOnAcquire) != 0 {
+		gd.decRefList.decRefAll()
+	} else {
+		gd.decRefObjectsIfNeeded()
+	}
+}
+
+// flushBeforeRelease applies a full batch of queued DecRefs before the GIL
+// is released.
+func flushBeforeRelease() {
+	if gd := getGlobalData(); gd != nil {
+		gd.decRefObjectsIfNeeded()
+	}
+}
@@ -0,0 +1,140 @@
+package gp
+
+import (
+	"fmt"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// queueDecRefs takes n references to __main__.x and drops them, then waits
+// for their finalizers to queue the DecRefs.
+func queueDecRefs(t *testing.T, n int) {
+	t.Helper()
+	func() {
+		m := MainModule()
+		for i := 0; i < n; i++ {
+			m.Attr("x")
+		}
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for getGlobalData().decRefList.len() < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d DecRefs queued, want %d", getGlobalData().decRefList.len(), n)
+		}
+		runtime.GC()
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// refCountOfX returns the reference count of __main__.x, without the
+// reference taken to read it.
+func refCountOfX() int {
+	x := MainModule().Attr("x")
+	defer x.Close()
+	return x.RefCount() - 1
+}
+
+func TestDecRefBatchSize(t *testing.T) {
+	setupTest(t)
+	SetDecRefPolicy(DecRefPolicy{BatchSize: 1000})
+	if err := RunString("x = object()"); err != nil {
+		t.Fatal(err)
+	}
+	base := refCountOfX()
+	queueDecRefs(t, 10)
+	if got := refCountOfX(); got != base+10 {
+		t.Errorf("refcount below batch size = %d, want %d", got, base+10)
+	}
+	FlushDecRefs()
+	if got := refCountOfX(); got != base {
+		t.Errorf("refcount after FlushDecRefs = %d, want %d", got, base)
+	}
+
+	// Attribute lookups apply a full batch, and so does releasing the GIL.
+	if prev := SetDecRefPolicy(DecRefPolicy{BatchSize: 10}); prev.BatchSize != 1000 {
+		t.Errorf("previous BatchSize = %d, want 1000", prev.BatchSize)
+	}
+	queueDecRefs(t, 10)
+	if got := refCountOfX(); got != base {
+		t.Errorf("refcount after a full batch = %d, want %d", got, base)
+	}
+	queueDecRefs(t, 10)
+	ReleaseGIL().Restore()
+	if n := getGlobalData().decRefList.len(); n != 0 {
+		t.Errorf("%d DecRefs still queued after releasing the GIL", n)
+	}
+}
+
+func TestDecRefDefaultPolicy(t *testing.T) {
+	setupTest(t)
+	SetDecRefPolicy(DecRefPolicy{BatchSize: DefaultDecRefBatchSize})
+	defer SetDecRefPolicy(DecRefPolicy{BatchSize: 1})
+	if err := RunString("x = object()\ndef f(a):\n    return a"); err != nil {
+		t.Fatal(err)
+	}
+	base := refCountOfX()
+	m := MainModule()
+	f := m.AttrFunc("f")
+	// A loop that never releases the GIL still frees what it drops.
+	for i := 0; i < 5000; i++ {
+		f.Call(m.Attr("x"))
+		if i%500 == 0 {
+			runtime.GC()
+		}
+	}
+	runtime.GC()
+	time.Sleep(10 * time.Millisecond)
+	f.Call(m.Attr("x"))
+	if n := getGlobalData().decRefList.len(); n >= DefaultDecRefBatchSize {
+		t.Errorf("%d DecRefs queued after 5000 calls", n)
+	}
+	if got := refCountOfX(); got > base+2*DefaultDecRefBatchSize {
+		t.Errorf("refcount after 5000 calls = %d, want at most %d", got, base+2*DefaultDecRefBatchSize)
+	}
+}
+
+func TestDecRefFlushOnAcquire(t *testing.T) {
+	setupTest(t)
+	SetDecRefPolicy(DecRefPolicy{BatchSize: 1000, FlushOnAcquire: true})
+	if err := RunString("x = object()"); err != nil {
+		t.Fatal(err)
+	}
+	base := refCountOfX()
+	queueDecRefs(t, 10)
+	s := ReleaseGIL()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		WithGIL(func() {})
+	}()
+	<-done
+	s.Restore()
+	if n := getGlobalData().decRefList.len(); n != 0 {
+		t.Errorf("%d DecRefs still queued after WithGIL", n)
+	}
+	if got := refCountOfX(); got != base {
+		t.Errorf("refcount = %d, want %d", got, base)
+	}
+}
+
+func TestDecRefFlushInterval(t *testing.T) {
+	setupTest(t)
+	SetDecRefPolicy(DecRefPolicy{BatchSize: 1000, FlushInterval: time.Millisecond})
+	defer SetDecRefPolicy(DecRefPolicy{BatchSize: 1})
+	if err := RunString("x = object()"); err != nil {
+		t.Fatal(err)
+	}
+	base := refCountOfX()
+	queueDecRefs(t, 10)
+	code := `
+import sys, time
+deadline = time.monotonic() + 5
+while sys.getrefcount(x) > %d and time.monotonic() < deadline:
+    time.sleep(0.001)
+assert sys.getrefcount(x) == %d, sys.getrefcount(x)
+`
+	if err := RunString(fmt.Sprintf(code, base+1, base+1)); err != nil {
+		t.Error(err)
+	}
+}
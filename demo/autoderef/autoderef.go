@@ -2,7 +2,6 @@ package main
 
 import (
 	"fmt"
-	"runtime"
 
 	. "github.com/gotray/go-python"
 	pymath "github.com/gotray/go-python/math"
@@ -47,18 +46,20 @@ for i in range(10):
 	memory_allocation_test := mod.AttrFunc("memory_allocation_test")
 
 	for i := 0; i < 100; i++ {
-		// 100MB every time
-		memory_allocation_test.Call()
+		// 100MB every time, released right away by Close
+		memory_allocation_test.Call().Close()
 		fmt.Printf("Iteration %d in go\n", i+1)
-		runtime.GC()
 	}
 
-	for i := 1; i <= 1000000; i++ {
-		f := MakeFloat(float64(i))
-		_ = pymath.Sqrt(f)
-		if i%10000 == 0 {
-			fmt.Printf("Iteration %d in go\n", i)
-		}
+	for i := 1; i <= 1000000; i += 10000 {
+		// Release the intermediate objects of every batch at once
+		WithScope(func(s *Scope) {
+			for j := i; j < i+10000; j++ {
+				f := MakeFloat(float64(j))
+				_ = pymath.Sqrt(f)
+			}
+		})
+		fmt.Printf("Iteration %d in go\n", i+9999)
 	}
 
 	fmt.Printf("Done\n")
@@ -22,10 +22,39 @@ static inline void pyCriticalSection_End(pyCriticalSection *pcs) {
     PyGILState_Release((PyGILState_STATE)pcs->_cs_prev);
 #endif
 }
+
+// pyDict_GetItemRef and pyDict_GetItemStringRef return a new reference, or
+// NULL if the key is missing. Borrowed references from PyDict_GetItem aren't
+// safe on free-threaded builds, where another thread may remove the item.
+static inline PyObject *pyDict_GetItemRef(PyObject *d, PyObject *key) {
+    PyObject *v = NULL;
+#if PY_VERSION_HEX >= 0x030D0000
+    if (PyDict_GetItemRef(d, key, &v) < 0) {
+        PyErr_Clear();
+    }
+#else
+    v = PyDict_GetItem(d, key);
+    Py_XINCREF(v);
+#endif
+    return v;
+}
+static inline PyObject *pyDict_GetItemStringRef(PyObject *d, const char *key) {
+    PyObject *v = NULL;
+#if PY_VERSION_HEX >= 0x030D0000
+    if (PyDict_GetItemStringRef(d, key, &v) < 0) {
+        PyErr_Clear();
+    }
+#else
+    v = PyDict_GetItemString(d, key);
+    Py_XINCREF(v);
+#endif
+    return v;
+}
 */
 import "C"
 import (
 	"fmt"
+	"runtime"
 	"unsafe"
 )
 
@@ -64,9 +93,7 @@ func (d Dict) HasKey(key any) bool {
 }
 
 func (d Dict) Get(key Objecter) Object {
-	v := C.PyDict_GetItem(d.obj, key.cpyObj())
-	C.Py_IncRef(v)
-	return newObject(v)
+	return newObject(C.pyDict_GetItemRef(d.obj, key.cpyObj()))
 }
 
 func (d Dict) Set(key, value Objecter) {
@@ -85,8 +112,7 @@ func (d Dict) SetString(key string, value Objecter) {
 
 func (d Dict) GetString(key string) Object {
 	ckey := AllocCStr(key)
-	v := C.PyDict_GetItemString(d.obj, ckey)
-	C.Py_IncRef(v)
+	v := C.pyDict_GetItemStringRef(d.obj, ckey)
 	C.free(unsafe.Pointer(ckey))
 	return newObject(v)
 }
@@ -96,15 +122,20 @@ func (d Dict) Del(key Objecter) {
 }
 
 func (d Dict) Items() func(func(Object, Object) bool) {
-	obj := d.cpyObj()
-	var cs C.pyCriticalSection
-	C.pyCriticalSection_Begin(&cs, obj)
 	return func(fn func(Object, Object) bool) {
+		obj := d.cpyObj()
+		// The critical section must begin and end on the same thread.
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+		var cs C.pyCriticalSection
+		C.pyCriticalSection_Begin(&cs, obj)
 		defer C.pyCriticalSection_End(&cs)
 		var pos C.Py_ssize_t
 		var key, value *C.PyObject
+		// Keep d alive, fn may call into Python and apply queued DecRefs.
+		defer runtime.KeepAlive(d)
 		for C.PyDict_Next(obj, &pos, &key, &value) == 1 {
-			if !fn(newObject(key), newObject(value)) {
+			if !fn(newObjectRef(key), newObjectRef(value)) {
 				return
 			}
 		}
@@ -0,0 +1,448 @@
+package gp
+
+/*
+#include <Python.h>
+*/
+import "C"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"runtime/debug"
+	"strings"
+	"unsafe"
+)
+
+// PyError is a Python exception captured as a Go error. It keeps the
+// exception class, the exception instance and the traceback, so callers can
+// match on the exception type instead of the formatted message:
+//
+//	var pyErr *gp.PyError
+//	if errors.As(err, &pyErr) && pyErr.Matches(builtins.Attr("KeyError")) {
+//		...
+//	}
+type PyError struct {
+	Type      Object
+	Value     Object
+	Traceback Object
+	Frames    []TracebackFrame
+
+	// typeName and message are read when the error is created, so Error
+	// never calls into Python, e.g. while a panic is printed after Finalize.
+	typeName string
+	message  string
+}
+
+// TracebackFrame is one entry of a Python traceback, ordered from the
+// outermost call to the frame that raised.
+type TracebackFrame struct {
+	Filename string
+	Line     int
+	Name     string
+}
+
+func (f TracebackFrame) String() string {
+	return fmt.Sprintf("File \"%s\", line %d, in %s", f.Filename, f.Line, f.Name)
+}
+
+func newPyError(ptype, pvalue, ptraceback *C.PyObject) *PyError {
+	C.PyErr_NormalizeException(&ptype, &pvalue, &ptraceback)
+	if ptraceback != nil && pvalue != nil {
+		C.PyException_SetTraceback(pvalue, ptraceback)
+	}
+	e := &PyError{Type: newObject(ptype)}
+	if pvalue != nil {
+		e.Value = newObject(pvalue)
+	} else {
+		e.Value = None()
+	}
+	if ptraceback != nil {
+		e.Traceback = newObject(ptraceback)
+		e.Frames = tracebackFrames(e.Traceback)
+	}
+	e.describe()
+	return e
+}
+
+// describe reads the type name and message of the exception.
+func (e *PyError) describe() {
+	e.typeName = e.Type.AttrString("__name__").String()
+	if e.Value.Nil() || e.Value.IsNone() {
+		return
+	}
+	s := C.PyObject_Str(e.Value.obj)
+	if s == nil {
+		C.PyErr_Clear()
+		e.message = "<exception str() failed>"
+		return
+	}
+	e.message = newStr(s).String()
+}
+
+func tracebackFrames(tb Object) (frames []TracebackFrame) {
+	for !tb.Nil() && !tb.IsNone() {
+		code := tb.Attr("tb_frame").Attr("f_code")
+		frames = append(frames, TracebackFrame{
+			Filename: code.AttrString("co_filename").String(),
+			Line:     tb.AttrLong("tb_lineno").Int(),
+			Name:     code.AttrString("co_name").String(),
+		})
+		tb = tb.Attr("tb_next")
+	}
+	return
+}
+
+// TypeName returns the name of the exception class, e.g. "KeyError".
+func (e *PyError) TypeName() string {
+	return e.typeName
+}
+
+// Message returns str() of the exception instance.
+func (e *PyError) Message() string {
+	return e.message
+}
+
+func (e *PyError) Error() string {
+	msg := e.Message()
+	if msg == "" {
+		return fmt.Sprintf("python error: %s", e.TypeName())
+	}
+	return fmt.Sprintf("python error: %s: %s", e.TypeName(), msg)
+}
+
+// Matches reports whether the exception is an instance of exc, which may be
+// an exception class or a tuple of classes. Subclasses match as in a Python
+// `except` clause.
+func (e *PyError) Matches(exc Objecter) bool {
+	return C.PyErr_GivenExceptionMatches(e.Type.obj, exc.cpyObj()) != 0
+}
+
+// FormatTraceback renders the error the way Python prints an uncaught
+// exception.
+func (e *PyError) FormatTraceback() string {
+	var sb strings.Builder
+	if len(e.Frames) > 0 {
+		sb.WriteString("Traceback (most recent call last):\n")
+		for _, f := range e.Frames {
+			sb.WriteString("  ")
+			sb.WriteString(f.String())
+			sb.WriteString("\n")
+		}
+	}
+	sb.WriteString(e.TypeName())
+	if msg := e.Message(); msg != "" {
+		sb.WriteString(": ")
+		sb.WriteString(msg)
+	}
+	return sb.String()
+}
+
+// Cause returns the exception's __cause__, set by `raise ... from cause`, or
+// nil if there is none.
+func (e *PyError) Cause() *PyError {
+	return pyErrorFromException(C.PyException_GetCause(e.Value.obj))
+}
+
+// Context returns the exception's __context__, the exception that was being
+// handled when this one was raised, or nil if there is none.
+func (e *PyError) Context() *PyError {
+	return pyErrorFromException(C.PyException_GetContext(e.Value.obj))
+}
+
+// pyErrorFromException wraps a new reference to an exception instance.
+func pyErrorFromException(exc *C.PyObject) *PyError {
+	if exc == nil {
+		return nil
+	}
+	return newPyError(C.PyObject_Type(exc), exc, C.PyException_GetTraceback(exc))
+}
+
+// ErrorMatches reports whether err is, or wraps, a *PyError matching exc.
+func ErrorMatches(err error, exc Objecter) bool {
+	var pyErr *PyError
+	return errors.As(err, &pyErr) && pyErr.Matches(exc)
+}
+
+// FetchError returns the current Python error as a *PyError and clears the
+// error indicator. It returns nil if no error is set.
+func FetchError() error {
+	var ptype, pvalue, ptraceback *C.PyObject
+	C.PyErr_Fetch(&ptype, &pvalue, &ptraceback)
+	if ptype == nil {
+		return nil
+	}
+	return newPyError(ptype, pvalue, ptraceback)
+}
+
+// ----------------------------------------------------------------------------
+
+// Standard Python exception classes, for use with SetError, NewError,
+// RegisterError and PyError.Matches.
+var (
+	BaseException       = exceptionClass(C.PyExc_BaseException)
+	Exception           = exceptionClass(C.PyExc_Exception)
+	ArithmeticError     = exceptionClass(C.PyExc_ArithmeticError)
+	AssertionError      = exceptionClass(C.PyExc_AssertionError)
+	AttributeError      = exceptionClass(C.PyExc_AttributeError)
+	ConnectionError     = exceptionClass(C.PyExc_ConnectionError)
+	EOFError            = exceptionClass(C.PyExc_EOFError)
+	FileExistsError     = exceptionClass(C.PyExc_FileExistsError)
+	FileNotFoundError   = exceptionClass(C.PyExc_FileNotFoundError)
+	ImportError         = exceptionClass(C.PyExc_ImportError)
+	IndexError          = exceptionClass(C.PyExc_IndexError)
+	KeyError            = exceptionClass(C.PyExc_KeyError)
+	KeyboardInterrupt   = exceptionClass(C.PyExc_KeyboardInterrupt)
+	LookupError         = exceptionClass(C.PyExc_LookupError)
+	MemoryError         = exceptionClass(C.PyExc_MemoryError)
+	ModuleNotFoundError = exceptionClass(C.PyExc_ModuleNotFoundError)
+	NameError           = exceptionClass(C.PyExc_NameError)
+	NotImplementedError = exceptionClass(C.PyExc_NotImplementedError)
+	OSError             = exceptionClass(C.PyExc_OSError)
+	OverflowError       = exceptionClass(C.PyExc_OverflowError)
+	PermissionError     = exceptionClass(C.PyExc_PermissionError)
+	RecursionError      = exceptionClass(C.PyExc_RecursionError)
+	RuntimeError        = exceptionClass(C.PyExc_RuntimeError)
+	StopIteration       = exceptionClass(C.PyExc_StopIteration)
+	SystemError         = exceptionClass(C.PyExc_SystemError)
+	TimeoutError        = exceptionClass(C.PyExc_TimeoutError)
+	TypeError           = exceptionClass(C.PyExc_TypeError)
+	UnicodeError        = exceptionClass(C.PyExc_UnicodeError)
+	ValueError          = exceptionClass(C.PyExc_ValueError)
+	ZeroDivisionError   = exceptionClass(C.PyExc_ZeroDivisionError)
+)
+
+// exceptionClass wraps a builtin exception class. These are static objects
+// that live as long as the process, so the wrapper holds no reference and has
+// no finalizer.
+func exceptionClass(exc *C.PyObject) Object {
+	return Object{&pyObject{obj: exc}}
+}
+
+// SetError raises an exception of class excType with message msg. Go
+// functions called from Python can use it to raise any exception class; the
+// return values of the function are then discarded.
+func SetError(excType Objecter, msg string) {
+	cmsg := AllocCStr(msg)
+	C.PyErr_SetString(excType.cpyObj(), cmsg)
+	C.free(unsafe.Pointer(cmsg))
+}
+
+// SetErrorObject raises an exception of class excType with value as its
+// argument, or value itself if it is already an instance of excType.
+func SetErrorObject(excType, value Objecter) {
+	C.PyErr_SetObject(excType.cpyObj(), value.cpyObj())
+}
+
+func SetTypeError(err error) {
+	SetError(TypeError, err.Error())
+}
+
+// SetErrorCause sets the __cause__ of the pending exception, as
+// `raise exc from cause` does. Passing None suppresses the context like
+// `raise exc from None`.
+func SetErrorCause(cause Objecter) {
+	setPendingChain(cause.cpyObj(), false)
+}
+
+// SetErrorContext sets the __context__ of the pending exception, marking it
+// as raised while cause was being handled.
+func SetErrorContext(context Objecter) {
+	setPendingChain(context.cpyObj(), true)
+}
+
+func setPendingChain(exc *C.PyObject, context bool) {
+	var ptype, pvalue, ptraceback *C.PyObject
+	C.PyErr_Fetch(&ptype, &pvalue, &ptraceback)
+	if ptype == nil {
+		return
+	}
+	C.PyErr_NormalizeException(&ptype, &pvalue, &ptraceback)
+	if ptraceback != nil {
+		C.PyException_SetTraceback(pvalue, ptraceback)
+	}
+	if C.Py_Is(exc, C.Py_None) != 0 {
+		exc = nil
+	}
+	C.Py_IncRef(exc)
+	if context {
+		C.PyException_SetContext(pvalue, exc)
+	} else {
+		C.PyException_SetCause(pvalue, exc)
+	}
+	C.PyErr_Restore(ptype, pvalue, ptraceback)
+}
+
+// NewError creates an exception of class excType with message msg as a Go
+// error. Returned from a Go function called by Python, it is raised as is:
+//
+//	func (c *Cache) Get(key string) (string, error) {
+//		if v, ok := c.items[key]; ok {
+//			return v, nil
+//		}
+//		return "", gp.NewError(gp.KeyError, key)
+//	}
+func NewError(excType Objecter, msg string) *PyError {
+	value := Func{excType.object()}.Call(msg)
+	e := &PyError{Type: value.Type(), Value: value}
+	e.describe()
+	return e
+}
+
+func newException(name string, base *C.PyObject, doc string) Object {
+	cname := AllocCStr(name)
+	cdoc := AllocCStr(doc)
+	exc := C.PyErr_NewExceptionWithDoc(cname, cdoc, base, nil)
+	C.free(unsafe.Pointer(cname))
+	C.free(unsafe.Pointer(cdoc))
+	return newObject(exc)
+}
+
+// GoPanicType returns the exception class raised into Python when a Go
+// function called from Python panics. It subclasses RuntimeError; instances
+// carry the panic value as `value` and the Go stack trace as `go_stack`.
+// Python code imports it with `from _gp import GoPanic`.
+func GoPanicType() Object {
+	return getGlobalData().goPanicType
+}
+
+// raiseGoPanic converts a recovered panic into a pending GoPanic exception.
+// It must be called from the deferred function that recovered r.
+func raiseGoPanic(r any) {
+	stack := string(debug.Stack())
+	C.PyErr_Clear()
+	value := fmt.Sprint(r)
+	exc, err := Func{GoPanicType()}.TryCall(fmt.Sprintf("go panic: %s", value))
+	if err != nil {
+		SetError(RuntimeError, fmt.Sprintf("go panic: %s", value))
+		return
+	}
+	exc.SetAttr("value", value)
+	exc.SetAttr("go_stack", stack)
+	C.PyErr_SetObject(exc.Type().obj, exc.obj)
+}
+
+// ----------------------------------------------------------------------------
+
+type errorMapping struct {
+	match func(error) (error, bool)
+	exc   Object
+}
+
+// RegisterError makes Go functions called from Python raise exc when they
+// return an error matching target according to errors.Is. Later
+// registrations take precedence over earlier ones.
+func RegisterError(target error, exc Objecter) {
+	getGlobalData().registerError(target, exc)
+}
+
+func (gd *globalData) registerError(target error, exc Objecter) {
+	gd.addErrorMapping(errorMapping{
+		match: func(err error) (error, bool) { return target, errors.Is(err, target) },
+		exc:   exc.object(),
+	})
+}
+
+// RegisterErrorType makes Go functions called from Python raise exc when
+// they return an error of type E, matched with errors.As. If E is a struct or
+// a pointer to one, its exported fields are set as attributes of the raised
+// exception, so with
+//
+//	type ValidationError struct{ Field string }
+//
+//	exc := m.AddException("ValidationError", gp.ValueError, "")
+//	gp.RegisterErrorType[*ValidationError](exc)
+//
+// Python code can use `except mod.ValidationError as e: e.field`.
+func RegisterErrorType[E error](exc Objecter) {
+	getGlobalData().addErrorMapping(errorMapping{
+		match: func(err error) (error, bool) {
+			var target E
+			if errors.As(err, &target) {
+				return target, true
+			}
+			return nil, false
+		},
+		exc: exc.object(),
+	})
+}
+
+func (gd *globalData) registerDefaultErrors() {
+	gd.registerError(io.EOF, EOFError)
+	gd.registerError(io.ErrUnexpectedEOF, EOFError)
+	gd.registerError(os.ErrNotExist, FileNotFoundError)
+	gd.registerError(os.ErrExist, FileExistsError)
+	gd.registerError(os.ErrPermission, PermissionError)
+	gd.registerError(os.ErrDeadlineExceeded, TimeoutError)
+	gd.registerError(context.DeadlineExceeded, TimeoutError)
+}
+
+// errorClass returns the exception class registered for err and the error
+// in its chain that matched, defaulting to RuntimeError and err itself.
+func (gd *globalData) errorClass(err error) (Object, error) {
+	gd.mu.RLock()
+	mappings := gd.errorMappings
+	gd.mu.RUnlock()
+	for i := len(mappings) - 1; i >= 0; i-- {
+		m := mappings[i]
+		if matched, ok := m.match(err); ok {
+			return m.exc, matched
+		}
+	}
+	return RuntimeError, err
+}
+
+// raiseGoError sets err as the pending Python exception. A *PyError found in
+// the chain is re-raised as the original exception; other errors are raised
+// as the class registered for them.
+func raiseGoError(err error) {
+	var pyErr *PyError
+	if errors.As(err, &pyErr) {
+		C.PyErr_SetObject(pyErr.Type.obj, pyErr.Value.obj)
+		return
+	}
+	excType, matched := getGlobalData().errorClass(err)
+	exc, callErr := Func{excType}.TryCall(err.Error())
+	if callErr != nil {
+		raiseGoError(callErr)
+		return
+	}
+	setErrorFields(exc, matched)
+	C.PyErr_SetObject(exc.Type().obj, exc.obj)
+}
+
+// setErrorFields copies the exported fields of a struct error to attributes
+// of the exception instance exc. Fields that have no Python counterpart,
+// such as funcs, channels and nested errors, are skipped.
+func setErrorFields(exc Object, err error) {
+	v := reflect.ValueOf(err)
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return
+	}
+	t := v.Type()
+	for _, f := range pyFields(t) {
+		fv := fieldByIndex(v, f.index, false)
+		if !fv.IsValid() {
+			continue
+		}
+		switch fv.Kind() {
+		case reflect.Interface, reflect.Func, reflect.Chan, reflect.UnsafePointer, reflect.Uintptr:
+			continue
+		case reflect.Ptr:
+			if fv.IsNil() {
+				exc.SetAttr(f.name, None())
+				continue
+			}
+		}
+		exc.SetAttr(f.name, fv.Interface())
+	}
+}
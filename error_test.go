@@ -0,0 +1,267 @@
+package gp
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestFetchError(t *testing.T) {
+	setupTest(t)
+
+	if err := FetchError(); err != nil {
+		t.Fatalf("FetchError() without pending error = %v, want nil", err)
+	}
+
+	code := `
+class MyKeyError(KeyError):
+    pass
+
+def lookup(d, k):
+    return d[k]
+
+def fail():
+    lookup({}, "missing")
+
+def fail_custom():
+    raise MyKeyError("custom")
+`
+	if err := RunString(code); err != nil {
+		t.Fatalf("RunString() error = %v", err)
+	}
+	builtins := ImportModule("builtins")
+	keyError := builtins.Attr("KeyError")
+	lookupError := builtins.Attr("LookupError")
+	valueError := builtins.Attr("ValueError")
+
+	err := RunString("fail()")
+	if err == nil {
+		t.Fatal("RunString() should fail")
+	}
+	var pyErr *PyError
+	if !errors.As(err, &pyErr) {
+		t.Fatalf("error %T is not a *PyError", err)
+	}
+	if got := pyErr.TypeName(); got != "KeyError" {
+		t.Errorf("TypeName() = %q, want %q", got, "KeyError")
+	}
+	if got := pyErr.Message(); got != "'missing'" {
+		t.Errorf("Message() = %q, want %q", got, "'missing'")
+	}
+	if got, want := err.Error(), "python error: KeyError: 'missing'"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+	if !pyErr.Matches(keyError) || !pyErr.Matches(lookupError) {
+		t.Error("KeyError should match KeyError and LookupError")
+	}
+	if pyErr.Matches(valueError) {
+		t.Error("KeyError should not match ValueError")
+	}
+	if !pyErr.Matches(MakeTuple(valueError, keyError)) {
+		t.Error("KeyError should match a tuple containing KeyError")
+	}
+
+	var names []string
+	for _, f := range pyErr.Frames {
+		names = append(names, f.Name)
+	}
+	if got, want := strings.Join(names, ","), "<module>,fail,lookup"; got != want {
+		t.Errorf("frames = %q, want %q", got, want)
+	}
+	if f := pyErr.Frames[len(pyErr.Frames)-1]; f.Filename != "<string>" || f.Line != 6 {
+		t.Errorf("innermost frame = %+v, want <string>:6", f)
+	}
+	tb := pyErr.FormatTraceback()
+	if !strings.HasPrefix(tb, "Traceback (most recent call last):\n") ||
+		!strings.HasSuffix(tb, "KeyError: 'missing'") ||
+		!strings.Contains(tb, `File "<string>", line 6, in lookup`) {
+		t.Errorf("unexpected traceback:\n%s", tb)
+	}
+
+	err = RunString("fail_custom()")
+	wrapped := fmt.Errorf("retry: %w", err)
+	if !ErrorMatches(wrapped, keyError) {
+		t.Error("ErrorMatches() should see subclass through wrapping")
+	}
+	if ErrorMatches(wrapped, valueError) {
+		t.Error("ErrorMatches() should not match ValueError")
+	}
+	if ErrorMatches(errors.New("plain"), keyError) {
+		t.Error("ErrorMatches() should not match non-Python errors")
+	}
+}
+
+func TestPyErrorText(t *testing.T) {
+	setupTest(t)
+
+	_, err := TryImportModule("no_such_module")
+	if err == nil {
+		t.Fatal("TryImportModule() should fail")
+	}
+	// Error must not call into Python, panics may be printed without the
+	// GIL or after Finalize.
+	s := ReleaseGIL()
+	got := err.Error()
+	s.Restore()
+	if want := "python error: ModuleNotFoundError: No module named 'no_such_module'"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+
+	if got, want := NewError(KeyError, "k").Error(), "python error: KeyError: 'k'"; got != want {
+		t.Errorf("NewError().Error() = %q, want %q", got, want)
+	}
+
+	code := `
+class BadStr(Exception):
+    def __str__(self):
+        raise ValueError("no str")
+
+raise BadStr()
+`
+	var pyErr *PyError
+	if err := RunString(code); !errors.As(err, &pyErr) {
+		t.Fatalf("RunString() error = %v, want a *PyError", err)
+	}
+	if got, want := pyErr.Error(), "python error: BadStr: <exception str() failed>"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+	if err := FetchError(); err != nil {
+		t.Errorf("str() failure left an error set: %v", err)
+	}
+}
+
+func TestSetError(t *testing.T) {
+	setupTest(t)
+	m := MainModule()
+	items := map[string]int{"a": 1}
+	values := []int{10, 20}
+
+	m.AddMethod("lookup", func(key string) int {
+		v, ok := items[key]
+		if !ok {
+			SetError(KeyError, key)
+			return 0
+		}
+		return v
+	}, "")
+	m.AddMethod("at", func(i int) (int, error) {
+		if i < 0 || i >= len(values) {
+			return 0, NewError(IndexError, "index out of range")
+		}
+		return values[i], nil
+	}, "")
+	m.AddMethod("stop", func() {
+		SetErrorObject(StopIteration, From(42))
+	}, "")
+	m.AddMethod("parse", func(s string) int {
+		_, err := ImportModule("builtins").AttrFunc("int").TryCall(s)
+		if err != nil {
+			SetError(ValueError, "cannot parse "+s)
+			SetErrorCause(err.(*PyError).Value)
+			return 0
+		}
+		return 1
+	}, "")
+	m.AddMethod("quiet", func() {
+		context := Func{KeyError}.Call("hidden")
+		SetError(ValueError, "quiet")
+		SetErrorContext(context)
+		SetErrorCause(None())
+	}, "")
+
+	code := `
+assert lookup("a") == 1
+try:
+    lookup("b")
+    assert False, "expected exception"
+except KeyError as e:
+    assert e.args == ("b",)
+
+def get(key, default=None):
+    try:
+        return lookup(key)
+    except KeyError:
+        return default
+assert get("missing", 5) == 5
+
+assert at(1) == 20
+try:
+    at(5)
+    assert False, "expected exception"
+except IndexError as e:
+    assert str(e) == "index out of range"
+
+try:
+    stop()
+    assert False, "expected exception"
+except StopIteration as e:
+    assert e.value == 42
+
+try:
+    parse("x")
+    assert False, "expected exception"
+except ValueError as e:
+    assert str(e) == "cannot parse x"
+    assert isinstance(e.__cause__, ValueError)
+    assert "invalid literal" in str(e.__cause__)
+
+try:
+    quiet()
+    assert False, "expected exception"
+except ValueError as e:
+    assert e.__cause__ is None
+    assert e.__suppress_context__
+    assert isinstance(e.__context__, KeyError)
+`
+	if err := RunString(code); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := m.AttrFunc("lookup").TryCall("b")
+	if !ErrorMatches(err, KeyError) || !ErrorMatches(err, LookupError) {
+		t.Errorf("lookup error = %v, want KeyError", err)
+	}
+	if KeyError.RefCount() <= 0 {
+		t.Error("KeyError should be alive")
+	}
+}
+
+func TestPyErrorChain(t *testing.T) {
+	setupTest(t)
+
+	err := RunString(`raise ValueError("outer") from KeyError("inner")`)
+	var pyErr *PyError
+	if !errors.As(err, &pyErr) {
+		t.Fatalf("error %T is not a *PyError", err)
+	}
+	cause := pyErr.Cause()
+	if cause == nil || !cause.Matches(KeyError) || cause.Message() != "'inner'" {
+		t.Errorf("Cause() = %v, want KeyError('inner')", cause)
+	}
+	if ctx := pyErr.Context(); ctx != nil {
+		t.Errorf("Context() = %v, want nil", ctx)
+	}
+
+	err = RunString(`
+def handler():
+    try:
+        {}["k"]
+    except KeyError:
+        raise RuntimeError("while handling")
+handler()
+`)
+	if !errors.As(err, &pyErr) {
+		t.Fatalf("error %T is not a *PyError", err)
+	}
+	if cause := pyErr.Cause(); cause != nil {
+		t.Errorf("Cause() = %v, want nil", cause)
+	}
+	ctx := pyErr.Context()
+	if ctx == nil || !ctx.Matches(KeyError) {
+		t.Fatalf("Context() = %v, want KeyError", ctx)
+	}
+	if len(ctx.Frames) == 0 || ctx.Frames[len(ctx.Frames)-1].Name != "handler" {
+		t.Errorf("context frames = %v, want to end in handler", ctx.Frames)
+	}
+}
@@ -0,0 +1,167 @@
+package gp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"sync"
+)
+
+// ErrExecutorClosed is returned for tasks submitted to a closed Executor.
+var ErrExecutorClosed = errors.New("gp: executor is closed")
+
+// PanicError is the error of a task that panicked.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("gp: task panicked: %v", e.Value)
+}
+
+// Executor owns the Python interpreter and runs tasks submitted from any
+// goroutine on a single OS thread. NewExecutor initializes Python and Close
+// finalizes it, so Initialize and Finalize must not be called while an
+// Executor is in use.
+//
+// The GIL is released while the executor waits for tasks, so Python threads
+// and goroutines using WithGIL keep running in between. Tasks must not wait
+// for other tasks of the same executor.
+type Executor struct {
+	tasks chan func()
+	done  chan struct{}
+
+	mu     sync.RWMutex // guards closed and sending to tasks
+	closed bool
+}
+
+// NewExecutor initializes Python on a new thread and returns an executor
+// running tasks on it. At most queueSize tasks wait in the queue; further
+// submissions block until there is room.
+func NewExecutor(queueSize int) *Executor {
+	e := &Executor{
+		tasks: make(chan func(), queueSize),
+		done:  make(chan struct{}),
+	}
+	ready := make(chan struct{})
+	go e.loop(ready)
+	<-ready
+	return e
+}
+
+func (e *Executor) loop(ready chan<- struct{}) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	defer close(e.done)
+
+	Initialize()
+	close(ready)
+	for {
+		s := ReleaseGIL()
+		task, ok := <-e.tasks
+		s.Restore()
+		if !ok {
+			break
+		}
+		task()
+		getGlobalData().decRefObjectsIfNeeded()
+	}
+	Finalize()
+}
+
+// Close waits for the queued tasks to finish, then finalizes Python. Tasks
+// submitted after Close fail with ErrExecutorClosed.
+func (e *Executor) Close() error {
+	e.mu.Lock()
+	if e.closed {
+		e.mu.Unlock()
+		return ErrExecutorClosed
+	}
+	e.closed = true
+	close(e.tasks)
+	e.mu.Unlock()
+	<-e.done
+	return nil
+}
+
+// submit queues task, blocking while the queue is full.
+func (e *Executor) submit(ctx context.Context, task func()) error {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.closed {
+		return ErrExecutorClosed
+	}
+	select {
+	case e.tasks <- task:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Run runs fn on the executor and waits for it to finish.
+func (e *Executor) Run(ctx context.Context, fn func()) error {
+	_, err := Submit(ctx, e, func() struct{} {
+		fn()
+		return struct{}{}
+	}).Wait(ctx)
+	return err
+}
+
+// Future is the result of a task submitted with Submit.
+type Future[T any] struct {
+	done  chan struct{}
+	value T
+	err   error
+}
+
+// Done returns a channel that is closed when the task has finished.
+func (f *Future[T]) Done() <-chan struct{} {
+	return f.done
+}
+
+// Wait waits for the task to finish and returns its result. If ctx is done
+// first, Wait returns ctx.Err() and the task keeps its place in the queue.
+func (f *Future[T]) Wait(ctx context.Context) (T, error) {
+	select {
+	case <-f.done:
+		return f.value, f.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+func (f *Future[T]) finish(value T, err error) {
+	f.value, f.err = value, err
+	close(f.done)
+}
+
+// Submit queues fn to run on the executor, blocking while the queue is full,
+// and returns a future for its result. If ctx is done before fn starts, fn
+// is skipped and the future fails with ctx.Err(). A panic in fn fails the
+// future with a *PanicError.
+func Submit[T any](ctx context.Context, e *Executor, fn func() T) *Future[T] {
+	f := &Future[T]{done: make(chan struct{})}
+	err := e.submit(ctx, func() {
+		var zero T
+		if err := ctx.Err(); err != nil {
+			f.finish(zero, err)
+			return
+		}
+		defer func() {
+			if r := recover(); r != nil {
+				f.finish(zero, &PanicError{Value: r, Stack: debug.Stack()})
+			}
+		}()
+		f.finish(fn(), nil)
+	})
+	if err != nil {
+		var zero T
+		f.finish(zero, err)
+	}
+	return f
+}
@@ -0,0 +1,101 @@
+package gp
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestExecutor(t *testing.T) {
+	testMutex.Lock()
+	defer testMutex.Unlock()
+
+	e := NewExecutor(4)
+	ctx := context.Background()
+	if err := e.Run(ctx, func() {
+		if err := RunString("def square(x):\n    return x * x"); err != nil {
+			t.Error(err)
+		}
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 32; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			f := Submit(ctx, e, func() int {
+				return MainModule().AttrFunc("square").Call(i).AsLong().Int()
+			})
+			if got, err := f.Wait(ctx); err != nil || got != i*i {
+				t.Errorf("square(%d) = %d, %v, want %d", i, got, err, i*i)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	_, err := Submit(ctx, e, func() int { panic("boom") }).Wait(ctx)
+	var panicErr *PanicError
+	if !errors.As(err, &panicErr) || panicErr.Value != "boom" {
+		t.Errorf("panicking task error = %v, want PanicError(boom)", err)
+	}
+
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Close(); !errors.Is(err, ErrExecutorClosed) {
+		t.Errorf("second Close() = %v, want ErrExecutorClosed", err)
+	}
+	if _, err := Submit(ctx, e, func() int { return 1 }).Wait(ctx); !errors.Is(err, ErrExecutorClosed) {
+		t.Errorf("Submit() after Close = %v, want ErrExecutorClosed", err)
+	}
+}
+
+func TestExecutorBackpressure(t *testing.T) {
+	testMutex.Lock()
+	defer testMutex.Unlock()
+
+	e := NewExecutor(1)
+	defer e.Close()
+	ctx := context.Background()
+
+	// Block the executor, then fill the queue.
+	release := make(chan struct{})
+	started := make(chan struct{})
+	blocker := Submit(ctx, e, func() bool {
+		close(started)
+		<-release
+		return true
+	})
+	<-started
+	queuedCtx, cancelQueued := context.WithCancel(ctx)
+	ran := false
+	queued := Submit(queuedCtx, e, func() bool {
+		ran = true
+		return true
+	})
+
+	// The queue is full, so submitting blocks until the deadline.
+	timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if _, err := Submit(timeoutCtx, e, func() bool { return true }).Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Submit() on full queue = %v, want DeadlineExceeded", err)
+	}
+
+	// A task cancelled while queued is skipped.
+	cancelQueued()
+	close(release)
+	if ok, err := blocker.Wait(ctx); !ok || err != nil {
+		t.Errorf("blocker = %v, %v", ok, err)
+	}
+	<-queued.Done()
+	if _, err := queued.Wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled task error = %v, want Canceled", err)
+	}
+	if ran {
+		t.Error("cancelled task should not run")
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"reflect"
 	"runtime"
+	"sort"
 	"strings"
 	"unicode"
 	"unsafe"
@@ -52,9 +53,36 @@ type slotMeta struct {
 	fn         any
 	doc        string
 	hasRecv    bool         // whether it has a receiver
-	index      int          // used for member type
+	index      []int        // used for member type
 	typ        reflect.Type // member/method type
 	def        *C.PyMethodDef
+	releaseGIL bool // release the GIL while the Go function runs
+}
+
+// MethodOption configures how Python calls a Go function added with
+// Module.AddMethod or the methods of a type added with Module.AddType.
+type MethodOption func(*slotMeta)
+
+// NoGIL releases the GIL while the Go function runs, so that other Python
+// threads can run while it blocks on I/O, channels or locks. Arguments are
+// converted before and results after the GIL is released, but the function
+// itself must not touch Python objects (including KwArgs values) except
+// inside WithGIL.
+func NoGIL() MethodOption {
+	return func(meta *slotMeta) {
+		meta.releaseGIL = true
+	}
+}
+
+// call calls the Go function, releasing the GIL around it if requested.
+func (meta *slotMeta) call(args []reflect.Value) []reflect.Value {
+	fn := reflect.ValueOf(meta.fn)
+	if !meta.releaseGIL {
+		return fn.Call(args)
+	}
+	s := ReleaseGIL()
+	defer s.Restore()
+	return fn.Call(args)
 }
 
 type typeMeta struct {
@@ -69,22 +97,19 @@ func allocWrapper(typ *C.PyTypeObject, obj any) *wrapperType {
 	wrapper := (*wrapperType)(unsafe.Pointer(self))
 	holder := new(objectHolder)
 	holder.obj = obj
-	maps := getGlobalData()
-	maps.holders.PushFront(holder)
+	getGlobalData().addHolder(holder)
 	wrapper.goObj = holder.obj
 	wrapper.holder = holder
 	return wrapper
 }
 
 func freeWrapper(wrapper *wrapperType) {
-	maps := getGlobalData()
-	maps.holders.Remove(wrapper.holder)
+	getGlobalData().removeHolder(wrapper.holder)
 }
 
 //export wrapperAlloc
 func wrapperAlloc(typ *C.PyTypeObject, size C.Py_ssize_t) *C.PyObject {
-	maps := getGlobalData()
-	meta := maps.typeMetas[(*C.PyObject)(unsafe.Pointer(typ))]
+	meta := getGlobalData().typeMeta((*C.PyObject)(unsafe.Pointer(typ)))
 	wrapper := allocWrapper(typ, reflect.New(meta.typ).Interface())
 	check(wrapper != nil, "failed to allocate wrapper")
 	return (*C.PyObject)(unsafe.Pointer(wrapper))
@@ -98,60 +123,77 @@ func wrapperDealloc(self *C.PyObject) {
 }
 
 //export wrapperInit
-func wrapperInit(self, args *C.PyObject) C.int {
+func wrapperInit(self, args *C.PyObject) (ret C.int) {
+	defer func() {
+		if r := recover(); r != nil {
+			raiseGoPanic(r)
+			ret = -1
+		}
+	}()
 	typ := (*C.PyObject)(self).ob_type
-	maps := getGlobalData()
-	typeMeta := maps.typeMetas[(*C.PyObject)(unsafe.Pointer(typ))]
+	typeMeta := getGlobalData().typeMeta((*C.PyObject)(unsafe.Pointer(typ)))
 	check(typeMeta != nil, "type not registered")
 	check(typeMeta.init != nil, "init method not found")
-	if wrapperMethod_(typeMeta, typeMeta.init, self, args, 0) == nil {
+	result := wrapperMethod_(typeMeta, typeMeta.init, self, args, 0)
+	if result == nil {
 		return -1
 	}
+	C.Py_DecRef(result)
 	return 0
 }
 
 //export getterMethod
-func getterMethod(self *C.PyObject, _closure unsafe.Pointer, methodId C.int) *C.PyObject {
+func getterMethod(self *C.PyObject, _closure unsafe.Pointer, methodId C.int) (ret *C.PyObject) {
+	defer func() {
+		if r := recover(); r != nil {
+			raiseGoPanic(r)
+			ret = nil
+		}
+	}()
 	maps := getGlobalData()
-	typeMeta := maps.typeMetas[(*C.PyObject)(unsafe.Pointer(self.ob_type))]
-	check(typeMeta != nil, fmt.Sprintf("type %v not registered", FromPy(self)))
-	methodMeta := typeMeta.methods[uint(methodId)]
+	typeMeta, methodMeta := maps.slot((*C.PyObject)(unsafe.Pointer(self.ob_type)), uint(methodId))
+	check(typeMeta != nil, fmt.Sprintf("type %v not registered", newObjectRef(self)))
 	check(methodMeta != nil, fmt.Sprintf("getter method %d not found", methodId))
 
 	wrapper := (*wrapperType)(unsafe.Pointer(self))
 	goPtr := reflect.ValueOf(wrapper.goObj)
-	goValue := goPtr.Elem()
-	field := goValue.Field(methodMeta.index)
+	field := fieldByIndex(goPtr.Elem(), methodMeta.index, false)
+	if !field.IsValid() {
+		// Promoted from a nil embedded struct pointer.
+		return None().newRef()
+	}
 
 	fieldType := field.Type()
 	if fieldType.Kind() == reflect.Ptr && fieldType.Elem().Kind() == reflect.Struct {
 		if field.IsNil() {
-			return C.Py_None
+			return None().newRef()
 		}
-		if pyType, ok := maps.pyTypes[fieldType.Elem()]; ok {
+		if pyType, ok := maps.pyType(fieldType.Elem()); ok {
 			newWrapper := allocWrapper((*C.PyTypeObject)(unsafe.Pointer(pyType)), field.Interface())
 			check(newWrapper != nil, "failed to allocate wrapper for nested struct pointer")
 			return (*C.PyObject)(unsafe.Pointer(newWrapper))
 		}
 	} else if field.Kind() == reflect.Struct {
-		if pyType, ok := maps.pyTypes[field.Type()]; ok {
-			baseAddr := goPtr.UnsafePointer()
-			fieldAddr := unsafe.Add(baseAddr, typeMeta.typ.Field(methodMeta.index).Offset)
-			fieldPtr := reflect.NewAt(fieldType, fieldAddr).Interface()
-			newWrapper := allocWrapper((*C.PyTypeObject)(unsafe.Pointer(pyType)), fieldPtr)
+		if pyType, ok := maps.pyType(field.Type()); ok {
+			newWrapper := allocWrapper((*C.PyTypeObject)(unsafe.Pointer(pyType)), field.Addr().Interface())
 			check(newWrapper != nil, "failed to allocate wrapper for nested struct")
 			return (*C.PyObject)(unsafe.Pointer(newWrapper))
 		}
 	}
-	return From(field.Interface()).cpyObj()
+	return From(field.Interface()).newRef()
 }
 
 //export setterMethod
-func setterMethod(self, value *C.PyObject, _closure unsafe.Pointer, methodId C.int) C.int {
+func setterMethod(self, value *C.PyObject, _closure unsafe.Pointer, methodId C.int) (ret C.int) {
+	defer func() {
+		if r := recover(); r != nil {
+			raiseGoPanic(r)
+			ret = -1
+		}
+	}()
 	maps := getGlobalData()
-	typeMeta := maps.typeMetas[(*C.PyObject)(unsafe.Pointer(self.ob_type))]
-	check(typeMeta != nil, fmt.Sprintf("type %v not registered", FromPy(self)))
-	methodMeta := typeMeta.methods[uint(methodId)]
+	typeMeta, methodMeta := maps.slot((*C.PyObject)(unsafe.Pointer(self.ob_type)), uint(methodId))
+	check(typeMeta != nil, fmt.Sprintf("type %v not registered", newObjectRef(self)))
 	check(methodMeta != nil, fmt.Sprintf("setter method %d not found", methodId))
 
 	wrapper := (*wrapperType)(unsafe.Pointer(self))
@@ -164,14 +206,14 @@ func setterMethod(self, value *C.PyObject, _closure unsafe.Pointer, methodId C.i
 		return -1
 	}
 
-	field := structValue.Field(methodMeta.index)
+	field := fieldByIndex(structValue, methodMeta.index, true)
 	if !field.CanSet() {
 		SetTypeError(fmt.Errorf("field %s cannot be set", methodMeta.name))
 		return -1
 	}
 
 	fieldType := field.Type()
-	if fieldType.Kind() == reflect.Ptr && fieldType.Elem().Kind() == reflect.Struct {
+	if fieldType.Kind() == reflect.Ptr && fieldType.Elem().Kind() == reflect.Struct && !isValueType(fieldType.Elem()) {
 		if C.Py_Is(value, C.Py_None) != 0 {
 			field.Set(reflect.Zero(fieldType))
 			return 0
@@ -180,14 +222,14 @@ func setterMethod(self, value *C.PyObject, _closure unsafe.Pointer, methodId C.i
 			if field.IsNil() {
 				field.Set(reflect.New(fieldType.Elem()))
 			}
-			if !ToValue(FromPy(value), field.Elem()) {
+			if !ToValue(newObjectRef(value), field.Elem()) {
 				SetTypeError(fmt.Errorf("failed to convert dict to %s", fieldType.Elem()))
 				return -1
 			}
 		} else {
 			pyType := C.Py_TYPE(value)
-			if _, ok := maps.typeMetas[(*C.PyObject)(unsafe.Pointer(pyType))]; !ok {
-				SetTypeError(fmt.Errorf("invalid value of type %v for struct pointer field", FromPy((*C.PyObject)(unsafe.Pointer(pyType)))))
+			if maps.typeMeta((*C.PyObject)(unsafe.Pointer(pyType))) == nil {
+				SetTypeError(fmt.Errorf("invalid value of type %v for struct pointer field", newObjectRef((*C.PyObject)(unsafe.Pointer(pyType)))))
 				return -1
 			}
 			valueWrapper := (*wrapperType)(unsafe.Pointer(value))
@@ -198,28 +240,25 @@ func setterMethod(self, value *C.PyObject, _closure unsafe.Pointer, methodId C.i
 			field.Set(reflect.ValueOf(valueWrapper.goObj))
 		}
 		return 0
-	} else if field.Kind() == reflect.Struct {
+	} else if field.Kind() == reflect.Struct && !isValueType(fieldType) {
 		if C.Py_IS_TYPE(value, &C.PyDict_Type) != 0 {
-			if !ToValue(FromPy(value), field) {
+			if !ToValue(newObjectRef(value), field) {
 				SetTypeError(fmt.Errorf("failed to convert dict to %s", field.Type()))
 				return -1
 			}
 		} else {
 			pyType := (*C.PyTypeObject)(unsafe.Pointer(value.ob_type))
-			if _, ok := maps.typeMetas[(*C.PyObject)(unsafe.Pointer(pyType))]; !ok {
-				SetTypeError(fmt.Errorf("invalid value of type %v for struct field", FromPy((*C.PyObject)(unsafe.Pointer(pyType)))))
+			if maps.typeMeta((*C.PyObject)(unsafe.Pointer(pyType))) == nil {
+				SetTypeError(fmt.Errorf("invalid value of type %v for struct field", newObjectRef((*C.PyObject)(unsafe.Pointer(pyType)))))
 				return -1
 			}
 			valueWrapper := (*wrapperType)(unsafe.Pointer(value))
-			baseAddr := goPtr.UnsafePointer()
-			fieldAddr := unsafe.Add(baseAddr, typeMeta.typ.Field(methodMeta.index).Offset)
-			fieldPtr := reflect.NewAt(fieldType, fieldAddr)
-			fieldPtr.Elem().Set(reflect.ValueOf(valueWrapper.goObj).Elem())
+			field.Set(reflect.ValueOf(valueWrapper.goObj).Elem())
 		}
 		return 0
 	}
 
-	if !ToValue(FromPy(value), field) {
+	if !ToValue(newObjectRef(value), field) {
 		SetTypeError(fmt.Errorf("failed to convert value to %s", methodMeta.typ))
 		return -1
 	}
@@ -227,17 +266,23 @@ func setterMethod(self, value *C.PyObject, _closure unsafe.Pointer, methodId C.i
 }
 
 //export wrapperMethod
-func wrapperMethod(self, args *C.PyObject, methodId C.int) *C.PyObject {
+func wrapperMethod(self, args *C.PyObject, methodId C.int) (ret *C.PyObject) {
+	// Queued DecRefs are applied when a Go callback returns, as no borrowed
+	// pointer of its own is in use any more.
+	defer getGlobalData().decRefObjectsIfNeeded()
+	defer func() {
+		if r := recover(); r != nil {
+			raiseGoPanic(r)
+			ret = nil
+		}
+	}()
 	key := self
 	if C.isModule(self) == 0 {
 		key = (*C.PyObject)(unsafe.Pointer(self.ob_type))
 	}
 
-	maps := getGlobalData()
-	typeMeta, ok := maps.typeMetas[key]
-	check(ok, fmt.Sprintf("type %v not registered", FromPy(key)))
-
-	methodMeta := typeMeta.methods[uint(methodId)]
+	typeMeta, methodMeta := getGlobalData().slot(key, uint(methodId))
+	check(typeMeta != nil, fmt.Sprintf("type %v not registered", newObjectRef(key)))
 	return wrapperMethod_(typeMeta, methodMeta, self, args, methodId)
 }
 
@@ -252,12 +297,23 @@ func wrapperMethod_(typeMeta *typeMeta, methodMeta *slotMeta, self, args *C.PyOb
 		expectedArgs-- // decrease expected number if it has a receiver
 	}
 
-	if int(argc) != expectedArgs {
+	if methodType.IsVariadic() {
+		// The variadic parameter takes the remaining arguments, if any.
+		expectedArgs--
+		if int(argc) < expectedArgs {
+			SetTypeError(fmt.Errorf("method %s expects at least %d arguments, got %d", methodMeta.name, expectedArgs, argc))
+			return nil
+		}
+	} else if int(argc) != expectedArgs {
 		SetTypeError(fmt.Errorf("method %s expects %d arguments, got %d", methodMeta.name, expectedArgs, argc))
 		return nil
 	}
 
-	goArgs := make([]reflect.Value, methodType.NumIn())
+	numArgs := int(argc)
+	if hasReceiver {
+		numArgs++
+	}
+	goArgs := make([]reflect.Value, numArgs)
 	argIndex := 0
 
 	if hasReceiver {
@@ -277,7 +333,12 @@ func wrapperMethod_(typeMeta *typeMeta, methodMeta *slotMeta, self, args *C.PyOb
 
 	for i := 0; i < int(argc); i++ {
 		arg := C.PySequence_GetItem(args, C.Py_ssize_t(i))
-		argType := methodType.In(i + argIndex)
+		var argType reflect.Type
+		if i < expectedArgs {
+			argType = methodType.In(i + argIndex)
+		} else {
+			argType = methodType.In(methodType.NumIn() - 1).Elem()
+		}
 		argPy := FromPy(arg)
 		goValue := reflect.New(argType).Elem()
 		if !ToValue(argPy, goValue) {
@@ -287,7 +348,10 @@ func wrapperMethod_(typeMeta *typeMeta, methodMeta *slotMeta, self, args *C.PyOb
 		goArgs[i+argIndex] = goValue
 	}
 
-	results := reflect.ValueOf(methodMeta.fn).Call(goArgs)
+	results, ok := splitErrorResult(methodMeta.call(goArgs))
+	if !ok {
+		return nil
+	}
 
 	// Handle init function return value
 	if isInit && !hasReceiver {
@@ -304,37 +368,259 @@ func wrapperMethod_(typeMeta *typeMeta, methodMeta *slotMeta, self, args *C.PyOb
 				// For value constructor
 				goObj.Set(result)
 			}
-			return (*C.PyObject)(unsafe.Pointer(wrapper))
+			C.Py_IncRef(self)
+			return self
 		} else {
 			panic("init function without receiver must return the type being created")
 		}
 	}
 
+	return returnValues(results)
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// splitErrorResult strips a trailing error result. A non-nil error is raised
+// as a Python exception and ok is false, as it is when the Go function set an
+// exception itself with SetError.
+func splitErrorResult(results []reflect.Value) (_ []reflect.Value, ok bool) {
+	n := len(results)
+	if n == 0 || results[n-1].Type() != errorType {
+		return results, C.PyErr_Occurred() == nil
+	}
+	if err := results[n-1]; !err.IsNil() {
+		raiseGoError(err.Interface().(error))
+		return nil, false
+	}
+	return results[:n-1], C.PyErr_Occurred() == nil
+}
+
+// returnValues converts the results of a Go call to a new reference owned by
+// the Python caller.
+func returnValues(results []reflect.Value) *C.PyObject {
 	if len(results) == 0 {
-		return None().cpyObj()
+		return None().newRef()
 	}
 	if len(results) == 1 {
-		return From(results[0].Interface()).cpyObj()
+		return From(results[0].Interface()).newRef()
 	}
 
 	tuple := MakeTupleWithLen(len(results))
 	for i := range results {
 		tuple.Set(i, From(results[i].Interface()))
 	}
-	return tuple.cpyObj()
+	return tuple.newRef()
+}
+
+// commonInitialisms are the acronyms goNameToPythonName splits runs of
+// uppercase letters into, as in HTTPURL.
+var commonInitialisms = []string{
+	"ACL", "API", "ASCII", "CPU", "CSS", "DNS", "EOF", "GUID", "HTML", "HTTP",
+	"HTTPS", "ID", "IP", "JSON", "LHS", "QPS", "RAM", "RHS", "RPC", "SLA",
+	"SMTP", "SQL", "SSH", "TCP", "TLS", "TTL", "UDP", "UI", "UID", "UUID",
+	"URI", "URL", "UTF8", "VM", "XML", "XMPP", "XSRF", "XSS",
+}
+
+// initialismLen returns the length of the longest common initialism run
+// starts with, or len(run) if there's none.
+func initialismLen(run []rune) int {
+	n := 0
+	for _, initialism := range commonInitialisms {
+		if len(initialism) > n && len(initialism) <= len(run) && string(run[:len(initialism)]) == initialism {
+			n = len(initialism)
+		}
+	}
+	if n == 0 {
+		return len(run)
+	}
+	return n
 }
 
+// goNameToPythonName converts a CamelCase Go name to snake_case, keeping
+// acronyms together: HTTPServer becomes http_server, HTTPURL http_url and
+// UserIDs user_ids.
 func goNameToPythonName(name string) string {
+	runes := []rune(name)
 	var result strings.Builder
-	for i, r := range name {
-		if i > 0 && unicode.IsUpper(r) {
+	for i := 0; i < len(runes); {
+		j := i + 1
+		if unicode.IsUpper(runes[i]) && j < len(runes) && unicode.IsUpper(runes[j]) {
+			for j < len(runes) && unicode.IsUpper(runes[j]) {
+				j++
+			}
+			plural := j < len(runes) && runes[j] == 's' && (j+1 == len(runes) || !unicode.IsLower(runes[j+1])) &&
+				initialismLen(runes[i:j]) == j-i
+			if j < len(runes) && unicode.IsLower(runes[j]) && !plural {
+				// The last uppercase letter starts the next word.
+				j--
+			}
+			j = i + initialismLen(runes[i:j])
+		}
+		for j < len(runes) && !unicode.IsUpper(runes[j]) {
+			j++
+		}
+		if i > 0 {
 			result.WriteRune('_')
 		}
-		result.WriteRune(unicode.ToLower(r))
+		result.WriteString(strings.ToLower(string(runes[i:j])))
+		i = j
 	}
 	return result.String()
 }
 
+// pyField is a struct field as seen from Python.
+type pyField struct {
+	index     []int // for reflect.Value.FieldByIndex
+	name      string
+	tagged    bool
+	omitEmpty bool
+	readOnly  bool
+}
+
+// pyFields returns the exported fields of the struct type t that Python sees.
+// A field is named by its `py` tag, or its `json` tag if it has no `py` tag,
+// or else by converting its Go name to snake_case. A tag name of "-" hides
+// the field. The omitempty option leaves zero values out of the dicts made
+// by From, and the readonly option makes the attribute read-only in types
+// added with AddType.
+//
+// Like encoding/json, the fields of untagged embedded structs are promoted:
+// a shallower field hides deeper ones of the same name, and among fields of
+// the same depth a tagged one wins, or else none does.
+func pyFields(t reflect.Type) []pyField {
+	type embedded struct {
+		typ   reflect.Type
+		index []int
+	}
+	var fields []pyField
+	visited := make(map[reflect.Type]bool)
+	for next := []embedded{{typ: t}}; len(next) > 0; {
+		current := next
+		next = nil
+		for _, e := range current {
+			if visited[e.typ] {
+				continue
+			}
+			for i := 0; i < e.typ.NumField(); i++ {
+				field := e.typ.Field(i)
+				ft := field.Type
+				if field.Anonymous && ft.Kind() == reflect.Ptr {
+					// Embedded pointers to unexported types can't be
+					// allocated to set promoted fields.
+					if !field.IsExported() {
+						continue
+					}
+					ft = ft.Elem()
+				}
+				if !field.IsExported() && !(field.Anonymous && ft.Kind() == reflect.Struct) {
+					continue
+				}
+				tag, ok := field.Tag.Lookup("py")
+				if !ok {
+					tag = field.Tag.Get("json")
+				}
+				if tag == "-" {
+					continue
+				}
+				index := append(append([]int(nil), e.index...), i)
+				name, opts, _ := strings.Cut(tag, ",")
+				if name == "" && field.Anonymous && ft.Kind() == reflect.Struct && !isValueType(ft) {
+					next = append(next, embedded{ft, index})
+					continue
+				}
+				if !field.IsExported() {
+					continue
+				}
+				f := pyField{index: index, name: name, tagged: name != ""}
+				if name == "" {
+					f.name = goNameToPythonName(field.Name)
+				}
+				for opts != "" {
+					var opt string
+					opt, opts, _ = strings.Cut(opts, ",")
+					switch opt {
+					case "omitempty":
+						f.omitEmpty = true
+					case "readonly":
+						f.readOnly = true
+					}
+				}
+				fields = append(fields, f)
+			}
+		}
+		// Embedding a type twice at the same depth promotes both copies of
+		// its fields, which then hide each other.
+		for _, e := range current {
+			visited[e.typ] = true
+		}
+	}
+	return dominantFields(fields)
+}
+
+// dominantFields keeps, for each name, the field Go would promote, in the
+// order of their declaration.
+func dominantFields(fields []pyField) []pyField {
+	byName := make(map[string][]pyField)
+	for _, f := range fields {
+		byName[f.name] = append(byName[f.name], f)
+	}
+	var dominant []pyField
+	for _, candidates := range byName {
+		depth := len(candidates[0].index)
+		var shallowest []pyField
+		for _, f := range candidates {
+			if len(f.index) < depth {
+				depth = len(f.index)
+				shallowest = shallowest[:0]
+			}
+			if len(f.index) == depth {
+				shallowest = append(shallowest, f)
+			}
+		}
+		if len(shallowest) > 1 {
+			var tagged []pyField
+			for _, f := range shallowest {
+				if f.tagged {
+					tagged = append(tagged, f)
+				}
+			}
+			shallowest = tagged
+		}
+		if len(shallowest) == 1 {
+			dominant = append(dominant, shallowest[0])
+		}
+	}
+	sort.Slice(dominant, func(i, j int) bool {
+		a, b := dominant[i].index, dominant[j].index
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+	return dominant
+}
+
+// fieldByIndex returns the field of the struct v at index, allocating the nil
+// embedded struct pointers on the way if alloc is true. It returns an invalid
+// Value if it meets a nil pointer otherwise.
+func fieldByIndex(v reflect.Value, index []int, alloc bool) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !alloc {
+					return reflect.Value{}
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
 func getMethods_(t reflect.Type, methods map[uint]*slotMeta) (ret []C.PyMethodDef) {
 	for i := 0; i < t.NumMethod(); i++ {
 		method := t.Method(i)
@@ -382,38 +668,36 @@ func getMethods(t reflect.Type, methods map[uint]*slotMeta) *C.PyMethodDef {
 func getGetsets(t reflect.Type, methods map[uint]*slotMeta) (getsets *C.PyGetSetDef) {
 	getsetsList := make([]C.PyGetSetDef, 0)
 
-	for i := 0; i < t.NumField(); i++ {
-		field := t.Field(i)
-		if !field.IsExported() {
-			continue
-		}
-
-		pythonName := goNameToPythonName(field.Name)
+	for _, f := range pyFields(t) {
+		field := t.FieldByIndex(f.index)
 
 		// Use getter/setter for all fields
 		getId := uint(len(methods))
 		methods[getId] = &slotMeta{
 			name:       field.Name,
-			methodName: pythonName,
+			methodName: f.name,
 			typ:        field.Type,
 			hasRecv:    false,
-			index:      i,
+			index:      f.index,
 		}
-		setId := uint(len(methods))
-		methods[setId] = &slotMeta{
-			name:       field.Name,
-			methodName: pythonName,
-			typ:        field.Type,
-			hasRecv:    false,
-			index:      i,
-		}
-		getsetsList = append(getsetsList, C.PyGetSetDef{
-			name:    AllocCStrDontFree(pythonName),
+		getset := C.PyGetSetDef{
+			name:    AllocCStrDontFree(f.name),
 			get:     C.getterMethods[getId],
-			set:     C.setterMethods[setId],
 			doc:     nil,
 			closure: nil,
-		})
+		}
+		if !f.readOnly {
+			setId := uint(len(methods))
+			methods[setId] = &slotMeta{
+				name:       field.Name,
+				methodName: f.name,
+				typ:        field.Type,
+				hasRecv:    false,
+				index:      f.index,
+			}
+			getset.set = C.setterMethods[setId]
+		}
+		getsetsList = append(getsetsList, getset)
 	}
 
 	// Add null terminators
@@ -433,7 +717,7 @@ func getGetsets(t reflect.Type, methods map[uint]*slotMeta) (getsets *C.PyGetSet
 	return getsetsPtr
 }
 
-func (m Module) AddType(obj, init any, name, doc string) Object {
+func (m Module) AddType(obj, init any, name, doc string, opts ...MethodOption) Object {
 	ty := reflect.TypeOf(obj)
 	if ty.Kind() == reflect.Ptr {
 		ty = ty.Elem()
@@ -444,7 +728,7 @@ func (m Module) AddType(obj, init any, name, doc string) Object {
 
 	// Check if type already registered
 	maps := getGlobalData()
-	if pyType, ok := maps.pyTypes[ty]; ok {
+	if pyType, ok := maps.pyType(ty); ok {
 		return newObjectRef(pyType)
 	}
 
@@ -490,10 +774,11 @@ func (m Module) AddType(obj, init any, name, doc string) Object {
 				typ:        initType,
 				hasRecv:    true,
 			}
-		} else if initType.NumOut() == 1 &&
+		} else if (initType.NumOut() == 1 ||
+			(initType.NumOut() == 2 && initType.Out(1) == errorType)) &&
 			(initType.Out(0) == ty ||
 				(initType.Out(0).Kind() == reflect.Ptr && initType.Out(0).Elem() == ty)) {
-			// Constructor function returning T or *T
+			// Constructor function returning T or *T, optionally with an error
 			meta.init = &slotMeta{
 				name:       runtime.FuncForPC(initVal.Pointer()).Name(),
 				methodName: "__init__",
@@ -507,8 +792,19 @@ func (m Module) AddType(obj, init any, name, doc string) Object {
 	}
 	getsets := getGetsets(ty, meta.methods)
 	slots = append(slots, C.PyType_Slot{slot: C.Py_tp_getset, pfunc: unsafe.Pointer(getsets)})
+	firstMethod := uint(len(meta.methods))
 	slots = append(slots, C.PyType_Slot{slot: C.Py_tp_methods, pfunc: unsafe.Pointer(getMethods(ty, meta.methods))})
 
+	// Options apply to the constructor and methods, not to field accessors.
+	for _, opt := range opts {
+		if meta.init != nil {
+			opt(meta.init)
+		}
+		for id := firstMethod; id < uint(len(meta.methods)); id++ {
+			opt(meta.methods[id])
+		}
+	}
+
 	slotCount := len(slots) + 1
 	slotSize := C.size_t(C.sizeof_PyType_Slot * slotCount)
 	slotsPtr := (*C.PyType_Slot)(C.malloc(slotSize))
@@ -537,29 +833,22 @@ func (m Module) AddType(obj, init any, name, doc string) Object {
 		panic(fmt.Sprintf("Failed to create type %s", name))
 	}
 
-	maps.typeMetas[typeObj] = meta
-	maps.pyTypes[ty] = typeObj
+	maps.addType(typeObj, meta)
 
 	if C.PyModule_AddObjectRef(m.obj, C.CString(name), typeObj) < 0 {
 		panic(fmt.Sprintf("Failed to add type %s to module", name))
 	}
 
 	// First register any struct field types
-	for i := 0; i < ty.NumField(); i++ {
-		field := ty.Field(i)
-		if !field.IsExported() {
-			continue
-		}
-
-		fieldType := field.Type
+	for _, f := range pyFields(ty) {
+		fieldType := ty.FieldByIndex(f.index).Type
 		// Handle pointer types
 		if fieldType.Kind() == reflect.Ptr {
 			fieldType = fieldType.Elem()
 		}
 		// Recursively register struct types
-		if fieldType.Kind() == reflect.Struct {
-			maps := getGlobalData()
-			if _, ok := maps.pyTypes[fieldType]; !ok {
+		if fieldType.Kind() == reflect.Struct && !isValueType(fieldType) {
+			if _, ok := maps.pyType(fieldType); !ok {
 				// Generate a unique type name based on package path and type name
 				nestedName := fieldType.Name()
 				m.AddType(reflect.New(fieldType).Elem().Interface(), nil, nestedName, "")
@@ -570,7 +859,22 @@ func (m Module) AddType(obj, init any, name, doc string) Object {
 	return newObjectRef(typeObj)
 }
 
-func (m Module) AddMethod(name string, fn any, doc string) Func {
+// AddException creates an exception class deriving from base, or from
+// Exception if base is nil, and adds it to the module as name. Use
+// RegisterError or RegisterErrorType to raise it from Go errors.
+func (m Module) AddException(name string, base Objecter, doc string) Object {
+	baseObj := Exception.obj
+	if base != nil && base.cpyObj() != nil {
+		baseObj = base.cpyObj()
+	}
+	exc := newException(fmt.Sprintf("%s.%s", m.Name(), name), baseObj, doc)
+	if m.AddObject(name, exc) < 0 {
+		panic(fmt.Sprintf("Failed to add exception %s to module", name))
+	}
+	return exc
+}
+
+func (m Module) AddMethod(name string, fn any, doc string, opts ...MethodOption) Func {
 	v := reflect.ValueOf(fn)
 	t := v.Type()
 	if t.Kind() != reflect.Func {
@@ -606,39 +910,34 @@ func (m Module) AddMethod(name string, fn any, doc string) Func {
 	fullDoc := name + sig + "\n--\n\n" + doc
 	cDoc := C.CString(fullDoc)
 
-	maps := getGlobalData()
-	meta, ok := maps.typeMetas[m.obj]
-	if !ok {
-		meta = &typeMeta{
-			methods: make(map[uint]*slotMeta),
-		}
-		maps.typeMetas[m.obj] = meta
-	}
-
-	methodId := uint(len(meta.methods))
-
 	cName := C.CString(name)
-
 	def := (*C.PyMethodDef)(C.malloc(C.size_t(unsafe.Sizeof(C.PyMethodDef{}))))
 	def.ml_name = cName
-	def.ml_meth = C.PyCFunction(C.wrapperMethods[methodId])
 	def.ml_flags = C.METH_VARARGS
 	if hasKwArgs {
 		def.ml_flags |= C.METH_KEYWORDS
-		def.ml_meth = C.PyCFunction(C.wrapperMethodsWithKwargs[methodId])
 	}
 	def.ml_doc = cDoc
 
-	methodMeta := &slotMeta{
-		name:       name,
-		methodName: name,
-		fn:         fn,
-		typ:        t,
-		doc:        fullDoc,
-		hasRecv:    hasRecv,
-		def:        def,
-	}
-	meta.methods[methodId] = methodMeta
+	getGlobalData().addModuleMethod(m.obj, func(methodId uint) *slotMeta {
+		def.ml_meth = C.PyCFunction(C.wrapperMethods[methodId])
+		if hasKwArgs {
+			def.ml_meth = C.PyCFunction(C.wrapperMethodsWithKwargs[methodId])
+		}
+		methodMeta := &slotMeta{
+			name:       name,
+			methodName: name,
+			fn:         fn,
+			typ:        t,
+			doc:        fullDoc,
+			hasRecv:    hasRecv,
+			def:        def,
+		}
+		for _, opt := range opts {
+			opt(methodMeta)
+		}
+		return methodMeta
+	})
 
 	pyFunc := C.PyCFunction_NewEx(def, m.obj, m.obj)
 	check(pyFunc != nil, fmt.Sprintf("Failed to create function %s", name))
@@ -651,44 +950,6 @@ func (m Module) AddMethod(name string, fn any, doc string) Func {
 	return newFunc(pyFunc)
 }
 
-func SetTypeError(err error) {
-	errStr := C.CString(err.Error())
-	C.PyErr_SetString(C.PyExc_TypeError, errStr)
-	C.free(unsafe.Pointer(errStr))
-}
-
-// FetchError returns the current Python error as a Go error
-func FetchError() error {
-	var ptype, pvalue, ptraceback *C.PyObject
-	C.PyErr_Fetch(&ptype, &pvalue, &ptraceback)
-	if ptype == nil {
-		return nil
-	}
-	defer C.Py_DecRef(ptype)
-	if pvalue == nil {
-		return fmt.Errorf("python error")
-	}
-	defer C.Py_DecRef(pvalue)
-	if ptraceback != nil {
-		defer C.Py_DecRef(ptraceback)
-	}
-
-	// Convert error to string
-	pyStr := C.PyObject_Str(pvalue)
-	if pyStr == nil {
-		return fmt.Errorf("python error (failed to get error message)")
-	}
-	defer C.Py_DecRef(pyStr)
-
-	// Get error message as Go string
-	cstr := C.PyUnicode_AsUTF8(pyStr)
-	if cstr == nil {
-		return fmt.Errorf("python error (failed to decode error message)")
-	}
-
-	return fmt.Errorf("python error: %s", C.GoString(cstr))
-}
-
 func genSig(fn any, hasRecv bool) string {
 	t := reflect.TypeOf(fn)
 	if t.Kind() != reflect.Func {
@@ -709,6 +970,10 @@ func genSig(fn any, hasRecv bool) string {
 		lastParamIdx-- // don't include KwArgs in regular parameters
 	}
 
+	variadic := t.IsVariadic() && !hasKwArgs
+	if variadic {
+		lastParamIdx--
+	}
 	for i := startIdx; i <= lastParamIdx; i++ {
 		paramName := fmt.Sprintf("arg%d", i-startIdx)
 		args = append(args, paramName)
@@ -719,6 +984,10 @@ func genSig(fn any, hasRecv bool) string {
 		args = append(args, "/")
 	}
 
+	if variadic {
+		args = append(args, "*args")
+	}
+
 	// add "**kwargs" if there are keyword arguments
 	if hasKwArgs {
 		args = append(args, "**kwargs")
@@ -728,17 +997,21 @@ func genSig(fn any, hasRecv bool) string {
 }
 
 //export wrapperMethodWithKwargs
-func wrapperMethodWithKwargs(self, args, kwargs *C.PyObject, methodId C.int) *C.PyObject {
+func wrapperMethodWithKwargs(self, args, kwargs *C.PyObject, methodId C.int) (ret *C.PyObject) {
+	defer getGlobalData().decRefObjectsIfNeeded()
+	defer func() {
+		if r := recover(); r != nil {
+			raiseGoPanic(r)
+			ret = nil
+		}
+	}()
 	key := self
 	if C.isModule(self) == 0 {
 		key = (*C.PyObject)(unsafe.Pointer(self.ob_type))
 	}
 
-	maps := getGlobalData()
-	typeMeta, ok := maps.typeMetas[key]
-	check(ok, fmt.Sprintf("type %v not registered", FromPy(key)))
-
-	methodMeta := typeMeta.methods[uint(methodId)]
+	typeMeta, methodMeta := getGlobalData().slot(key, uint(methodId))
+	check(typeMeta != nil, fmt.Sprintf("type %v not registered", newObjectRef(key)))
 	methodType := methodMeta.typ
 	hasReceiver := methodMeta.hasRecv
 
@@ -786,7 +1059,7 @@ func wrapperMethodWithKwargs(self, args, kwargs *C.PyObject, methodId C.int) *C.
 
 	kwargsValue := make(KwArgs)
 	if kwargs != nil {
-		dict := newDict(kwargs)
+		dict := Dict{newObjectRef(kwargs)}
 		dict.Items()(func(key, value Object) bool {
 			kwargsValue[key.String()] = value
 			return true
@@ -794,18 +1067,9 @@ func wrapperMethodWithKwargs(self, args, kwargs *C.PyObject, methodId C.int) *C.
 	}
 	goArgs[len(goArgs)-1] = reflect.ValueOf(kwargsValue)
 
-	results := reflect.ValueOf(methodMeta.fn).Call(goArgs)
-
-	if len(results) == 0 {
-		return None().cpyObj()
-	}
-	if len(results) == 1 {
-		return From(results[0].Interface()).cpyObj()
-	}
-
-	tuple := MakeTupleWithLen(len(results))
-	for i := range results {
-		tuple.Set(i, From(results[i].Interface()))
+	results, ok := splitErrorResult(methodMeta.call(goArgs))
+	if !ok {
+		return nil
 	}
-	return tuple.cpyObj()
+	return returnValues(results)
 }
@@ -1,7 +1,12 @@
 package gp
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
+	"reflect"
+	"runtime"
 	"strings"
 	"testing"
 )
@@ -991,6 +996,12 @@ func TestGenSig(t *testing.T) {
 			hasRecv:     false,
 			expectedSig: "(**kwargs)",
 		},
+		{
+			name:        "variadic",
+			fn:          func(sep string, parts ...int) {},
+			hasRecv:     false,
+			expectedSig: "(arg0, /, *args)",
+		},
 	}
 
 	for _, tt := range tests {
@@ -1116,3 +1127,473 @@ func TestAddMethodNaming(t *testing.T) {
 		}()
 	}
 }
+
+func TestGoNameToPythonName(t *testing.T) {
+	tests := map[string]string{
+		"Name":        "name",
+		"myFunc":      "my_func",
+		"HTTPURL":     "http_url",
+		"HTTPServer":  "http_server",
+		"ServeHTTP":   "serve_http",
+		"UserID":      "user_id",
+		"UserIDs":     "user_ids",
+		"ID":          "id",
+		"UTF8String":  "utf8_string",
+		"Base64Value": "base64_value",
+		"ABCValue":    "abc_value",
+		"X":           "x",
+	}
+	for name, want := range tests {
+		if got := goNameToPythonName(name); got != want {
+			t.Errorf("goNameToPythonName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+type taggedPoint struct {
+	X       int    `py:"x_pos"`
+	Y       int    `json:"y_pos"`
+	Label   string `py:"label,readonly"`
+	Secret  string `py:"-"`
+	TLSPort int
+}
+
+func TestAddTypeStructTags(t *testing.T) {
+	setupTest(t)
+	m := MainModule()
+	m.AddType(taggedPoint{}, nil, "TaggedPoint", "")
+	point := &taggedPoint{Label: "p", Secret: "s"}
+	m.AddObject("point", From(point))
+
+	err := RunString(`
+point.x_pos = 1
+point.y_pos = 2
+point.tls_port = 443
+assert point.label == "p"
+try:
+    point.label = "q"
+    raise AssertionError("label is writable")
+except AttributeError:
+    pass
+assert not hasattr(point, "secret")
+assert not hasattr(point, "x")
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := taggedPoint{X: 1, Y: 2, Label: "p", Secret: "s", TLSPort: 443}
+	if *point != want {
+		t.Errorf("point = %+v, want %+v", *point, want)
+	}
+}
+
+type embedBase struct {
+	ID    int
+	Label string `py:"label"`
+}
+
+type EmbedMeta struct {
+	ID      int
+	Label   string
+	Created string
+}
+
+type embedHidden struct {
+	Value int
+}
+
+type embedOuter struct {
+	embedBase
+	*EmbedMeta
+	Name   string
+	Hidden *embedHidden `py:"-"`
+}
+
+func TestEmbeddedFields(t *testing.T) {
+	setupTest(t)
+	dict := From(embedOuter{embedBase: embedBase{ID: 1, Label: "b"}, Name: "n"}).AsDict()
+	want := map[any]any{"label": "b", "name": "n"}
+	if got, err := To[map[any]any](dict.Object); err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("From(embedOuter) = %v, %v, want %v", got, err, want)
+	}
+
+	outer, err := To[embedOuter](From(map[string]any{"label": "x", "created": "c", "id": 5, "name": "y"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if outer.embedBase != (embedBase{Label: "x"}) || outer.EmbedMeta == nil || *outer.EmbedMeta != (EmbedMeta{Created: "c"}) || outer.Name != "y" {
+		t.Errorf("To[embedOuter]() = %+v, %+v", outer, outer.EmbedMeta)
+	}
+
+	m := MainModule()
+	m.AddType(embedOuter{}, nil, "EmbedOuter", "")
+	if _, ok := getGlobalData().pyType(reflect.TypeOf(embedHidden{})); ok {
+		t.Error("AddType registered the type of a hidden field")
+	}
+	obj := &embedOuter{embedBase: embedBase{Label: "b"}}
+	m.AddObject("obj", From(obj))
+	err = RunString(`
+assert obj.label == "b"
+assert obj.created is None
+obj.created = "now"
+assert obj.created == "now"
+assert not hasattr(obj, "id")
+assert not hasattr(obj, "hidden")
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if obj.EmbedMeta == nil || obj.Created != "now" {
+		t.Errorf("obj.EmbedMeta = %+v", obj.EmbedMeta)
+	}
+}
+
+type panicStruct struct {
+	Value int
+}
+
+func (p *panicStruct) Explode(msg string) int {
+	panic(msg)
+}
+
+func TestGoPanicRaisesPythonException(t *testing.T) {
+	setupTest(t)
+	m := MainModule()
+
+	m.AddMethod("go_boom", func(n int) int {
+		var s []int
+		return s[n]
+	}, "")
+	m.AddMethod("go_boom_kw", func(kw KwArgs) {
+		panic(fmt.Errorf("bad kwargs: %d", len(kw)))
+	}, "")
+	m.AddType(panicStruct{}, func(p *panicStruct, v int) {
+		if v < 0 {
+			panic("negative value")
+		}
+		p.Value = v
+	}, "PanicStruct", "")
+	m.AddMethod("go_panic_type", GoPanicType, "")
+
+	code := `
+from _gp import GoPanic
+assert GoPanic is go_panic_type()
+assert issubclass(GoPanic, RuntimeError)
+
+try:
+    go_boom(3)
+    assert False, "expected exception"
+except RuntimeError as e:
+    assert type(e) is GoPanic, type(e)
+    assert "index out of range" in str(e), str(e)
+    assert "index out of range" in e.value
+    assert "goroutine" in e.go_stack
+
+try:
+    go_boom_kw(a=1, b=2)
+    assert False, "expected exception"
+except GoPanic as e:
+    assert e.value == "bad kwargs: 2", e.value
+
+try:
+    PanicStruct(-1)
+    assert False, "expected exception"
+except GoPanic as e:
+    assert e.value == "negative value"
+
+p = PanicStruct(5)
+try:
+    p.explode("method panic")
+    assert False, "expected exception"
+except GoPanic as e:
+    assert e.value == "method panic"
+assert p.value == 5
+`
+	if err := RunString(code); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := m.AttrFunc("go_boom").TryCall(1)
+	if !ErrorMatches(err, GoPanicType()) {
+		t.Errorf("TryCall() error = %v, want GoPanic", err)
+	}
+	if !strings.Contains(err.Error(), "go panic: runtime error: index out of range") {
+		t.Errorf("unexpected error message: %v", err)
+	}
+}
+
+type quotaError struct {
+	Limit int
+}
+
+func (e *quotaError) Error() string {
+	return fmt.Sprintf("quota %d exceeded", e.Limit)
+}
+
+var errRetry = errors.New("retry later")
+
+type errorInitStruct struct {
+	Name string
+}
+
+func newErrorInitStruct(name string) (*errorInitStruct, error) {
+	if name == "" {
+		return nil, fmt.Errorf("empty name: %w", os.ErrInvalid)
+	}
+	return &errorInitStruct{Name: name}, nil
+}
+
+func TestGoErrorRaisesPythonException(t *testing.T) {
+	setupTest(t)
+	m := MainModule()
+	builtins := ImportModule("builtins")
+
+	RegisterError(errRetry, builtins.Attr("ConnectionError"))
+	RegisterErrorType[*quotaError](builtins.Attr("OverflowError"))
+
+	m.AddMethod("div", func(a, b int) (int, error) {
+		if b == 0 {
+			return 0, errors.New("division by zero")
+		}
+		return a / b, nil
+	}, "")
+	m.AddMethod("divmod_go", func(a, b int) (int, int, error) {
+		return a / b, a % b, nil
+	}, "")
+	m.AddMethod("check", func(name string) error {
+		switch name {
+		case "missing":
+			return fmt.Errorf("open %s: %w", name, os.ErrNotExist)
+		case "slow":
+			return context.DeadlineExceeded
+		case "retry":
+			return fmt.Errorf("backend: %w", errRetry)
+		case "quota":
+			return fmt.Errorf("wrapped: %w", &quotaError{Limit: 3})
+		case "python":
+			_, err := ImportModule("builtins").AttrFunc("int").TryCall("x")
+			return err
+		}
+		return nil
+	}, "")
+	m.AddType(errorInitStruct{}, newErrorInitStruct, "ErrorInitStruct", "")
+
+	code := `
+assert div(7, 2) == 3
+try:
+    div(1, 0)
+    assert False, "expected exception"
+except RuntimeError as e:
+    assert str(e) == "division by zero", str(e)
+
+assert divmod_go(7, 2) == (3, 1)
+assert check("ok") is None
+
+try:
+    check("missing")
+    assert False, "expected exception"
+except FileNotFoundError as e:
+    assert "file does not exist" in str(e), str(e)
+
+try:
+    check("slow")
+    assert False, "expected exception"
+except TimeoutError:
+    pass
+
+try:
+    check("retry")
+    assert False, "expected exception"
+except ConnectionError as e:
+    assert str(e) == "backend: retry later"
+
+try:
+    check("quota")
+    assert False, "expected exception"
+except OverflowError as e:
+    assert str(e) == "wrapped: quota 3 exceeded"
+
+try:
+    check("python")
+    assert False, "expected exception"
+except ValueError as e:
+    assert "invalid literal" in str(e)
+
+assert ErrorInitStruct("a").name == "a"
+try:
+    ErrorInitStruct("")
+    assert False, "expected exception"
+except RuntimeError as e:
+    assert str(e) == "empty name: invalid argument"
+`
+	if err := RunString(code); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExportedFunctionReturnOwnership(t *testing.T) {
+	setupTest(t)
+	m := MainModule()
+	m.AddMethod("make_str", func(i int) string {
+		return fmt.Sprintf("dynamic string %d", i)
+	}, "")
+	m.AddMethod("make_obj", func() Object {
+		return MakeList(1, 2, 3).Object
+	}, "")
+
+	if err := RunString("values = [make_str(i) for i in range(10)]\nobj = make_obj()"); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		runtime.GC()
+		getGlobalData().decRefObjectsIfNeeded()
+	}
+	code := `
+import sys
+assert values == ["dynamic string %d" % i for i in range(10)]
+assert sys.getrefcount(values[0]) == 2, sys.getrefcount(values[0])
+assert obj == [1, 2, 3]
+assert sys.getrefcount(obj) == 2, sys.getrefcount(obj)
+`
+	if err := RunString(code); err != nil {
+		t.Fatal(err)
+	}
+}
+
+type argTaker struct{}
+
+func (a *argTaker) Take(o Object) {}
+
+func TestExportedFunctionArgumentOwnership(t *testing.T) {
+	setupTest(t)
+	m := MainModule()
+	m.AddMethod("take_obj", func(o Object) {}, "")
+	m.AddType(argTaker{}, nil, "ArgTaker", "")
+	if err := RunString("x = [1, 2, 3]\ntaker = ArgTaker()"); err != nil {
+		t.Fatal(err)
+	}
+	x := m.Attr("x")
+	got := refCountDelta(t, x, func() {
+		if err := RunString("for _ in range(100):\n    take_obj(x)\n    taker.take(x)"); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if got != 0 {
+		t.Errorf("refcount of an argument changed by %d after 100 calls", got)
+	}
+}
+
+type ValidationError struct {
+	Field  string
+	Reason string
+	Limit  *int
+	Err    error
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Reason)
+}
+
+func TestAddException(t *testing.T) {
+	setupTest(t)
+	m := CreateModule("foo")
+	GetModuleDict().SetString("foo", m)
+
+	exc := m.AddException("ValidationError", ValueError, "Invalid input.")
+	base := m.AddException("FooError", nil, "")
+	RegisterErrorType[*ValidationError](exc)
+
+	m.AddMethod("validate", func(name string) (string, error) {
+		if name == "" {
+			limit := 3
+			return "", fmt.Errorf("validate: %w", &ValidationError{
+				Field: "name", Reason: "empty", Limit: &limit, Err: os.ErrInvalid,
+			})
+		}
+		if len(name) > 3 {
+			return "", &ValidationError{Field: "name", Reason: "too long"}
+		}
+		return name, nil
+	}, "")
+
+	code := `
+import foo
+assert foo.ValidationError.__module__ == "foo"
+assert foo.ValidationError.__name__ == "ValidationError"
+assert foo.ValidationError.__doc__ == "Invalid input."
+assert issubclass(foo.ValidationError, ValueError)
+assert issubclass(foo.FooError, Exception)
+
+assert foo.validate("abc") == "abc"
+try:
+    foo.validate("")
+    assert False, "expected exception"
+except foo.ValidationError as e:
+    assert str(e) == "validate: name: empty", str(e)
+    assert e.field == "name"
+    assert e.reason == "empty"
+    assert e.limit == 3
+    assert not hasattr(e, "err")
+
+try:
+    foo.validate("abcd")
+    assert False, "expected exception"
+except ValueError as e:
+    assert e.reason == "too long"
+    assert e.limit is None
+`
+	if err := RunString(code); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := m.AttrFunc("validate").TryCall("")
+	if !ErrorMatches(err, exc) || !ErrorMatches(err, ValueError) || ErrorMatches(err, base) {
+		t.Errorf("validate error = %v, want foo.ValidationError", err)
+	}
+}
+
+type variadicJoiner struct {
+	Sep string
+}
+
+func (j *variadicJoiner) Join(parts ...string) string {
+	return strings.Join(parts, j.Sep)
+}
+
+func TestVariadicMethods(t *testing.T) {
+	setupTest(t)
+	m := MainModule()
+	m.AddMethod("sum_all", func(base int, nums ...int) int {
+		for _, n := range nums {
+			base += n
+		}
+		return base
+	}, "")
+	m.AddMethod("count_args", func(args ...Object) int {
+		return len(args)
+	}, "")
+	m.AddType(variadicJoiner{}, nil, "VariadicJoiner", "")
+	m.AddObject("joiner", From(&variadicJoiner{Sep: "-"}))
+
+	code := `
+assert sum_all(1) == 1
+assert sum_all(1, 2, 3, 4) == 10
+assert count_args() == 0
+assert count_args(None, "a", [1]) == 3
+assert joiner.join() == ""
+assert joiner.join("a", "b", "c") == "a-b-c"
+assert sum_all.__text_signature__ == "(arg0, /, *args)", sum_all.__text_signature__
+assert count_args.__text_signature__ == "(*args)", count_args.__text_signature__
+
+for call in (lambda: sum_all(), lambda: sum_all(1, "x"), lambda: joiner.join("a", 1), lambda: sum_all(1, nums=2)):
+    try:
+        call()
+    except TypeError:
+        pass
+    else:
+        raise AssertionError("expected TypeError")
+`
+	if err := RunString(code); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -28,6 +28,5 @@ func (f Float) Float32() float32 {
 }
 
 func (f Float) IsInteger() Bool {
-	fn := cast[Func](f.Attr("is_integer"))
-	return cast[Bool](fn.callNoArgs())
+	return cast[Bool](f.Call("is_integer"))
 }
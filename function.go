@@ -27,47 +27,49 @@ func (f Func) Name() string {
 	return f.AttrString("__name__").String()
 }
 
-func (f Func) call(args Tuple, kwargs Dict) Object {
-	return newObject(C.PyObject_Call(f.obj, args.obj, kwargs.obj))
-}
-
-func (f Func) callNoArgs() Object {
-	return newObject(C.PyObject_CallNoArgs(f.obj))
-}
-
-func (f Func) callOneArg(arg Objecter) Object {
-	return newObject(C.PyObject_CallOneArg(f.obj, arg.cpyObj()))
+func (f Func) CallObject(args Tuple) Object {
+	return must(f.tryCallObject(args))
 }
 
-func (f Func) CallObject(args Tuple) Object {
+func (f Func) tryCallObject(args Tuple) (Object, error) {
 	defer getGlobalData().decRefObjectsIfNeeded()
-	return newObject(C.PyObject_CallObject(f.obj, args.obj))
+	return tryObject(C.PyObject_CallObject(f.obj, args.obj))
 }
 
 func (f Func) CallObjectKw(args Tuple, kw KwArgs) Object {
+	return must(f.tryCallObjectKw(args, kw))
+}
+
+func (f Func) tryCallObjectKw(args Tuple, kw KwArgs) (Object, error) {
 	defer getGlobalData().decRefObjectsIfNeeded()
 	// Convert keyword arguments to Python dict
 	kwDict := MakeDict(nil)
 	for k, v := range kw {
 		kwDict.Set(MakeStr(k), From(v))
 	}
-	return f.call(args, kwDict)
+	return tryObject(C.PyObject_Call(f.obj, args.obj, kwDict.obj))
 }
 
 func (f Func) Call(args ...any) Object {
+	return must(f.TryCall(args...))
+}
+
+// TryCall is like Call but returns the Python exception as a *PyError
+// instead of panicking.
+func (f Func) TryCall(args ...any) (Object, error) {
 	argsTuple, kwArgs := splitArgs(args...)
 	if kwArgs == nil {
 		defer getGlobalData().decRefObjectsIfNeeded()
 		switch len(args) {
 		case 0:
-			return f.callNoArgs()
+			return tryObject(C.PyObject_CallNoArgs(f.obj))
 		case 1:
-			return f.callOneArg(From(args[0]))
+			return tryObject(C.PyObject_CallOneArg(f.obj, From(args[0]).obj))
 		default:
-			return f.CallObject(argsTuple)
+			return f.tryCallObject(argsTuple)
 		}
 	} else {
-		return f.CallObjectKw(argsTuple, kwArgs)
+		return f.tryCallObjectKw(argsTuple, kwArgs)
 	}
 }
 
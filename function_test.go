@@ -57,3 +57,37 @@ func TestFuncCall(t *testing.T) {
 		t.Errorf("Expected pow(2, 3) to be 8, got %v", result)
 	}
 }
+
+func TestFuncTryCall(t *testing.T) {
+	setupTest(t)
+	builtins := ImportModule("builtins")
+	lenFunc := builtins.AttrFunc("len")
+
+	result, err := lenFunc.TryCall(MakeList(1, 2))
+	if err != nil {
+		t.Fatalf("TryCall() error = %v", err)
+	}
+	if result.AsLong().Int64() != 2 {
+		t.Errorf("Expected len([1,2]) to be 2, got %v", result)
+	}
+
+	_, err = lenFunc.TryCall()
+	if !ErrorMatches(err, builtins.Attr("TypeError")) {
+		t.Errorf("TryCall() with no args error = %v, want TypeError", err)
+	}
+
+	_, err = lenFunc.TryCall(42, KwArgs{"x": 1})
+	if !ErrorMatches(err, builtins.Attr("TypeError")) {
+		t.Errorf("TryCall() with kwargs error = %v, want TypeError", err)
+	}
+
+	intFunc := builtins.AttrFunc("int")
+	_, err = intFunc.TryCall("abc")
+	if !ErrorMatches(err, builtins.Attr("ValueError")) {
+		t.Errorf("int('abc') error = %v, want ValueError", err)
+	}
+	result, err = intFunc.TryCall("ff", KwArgs{"base": 16})
+	if err != nil || result.AsLong().Int64() != 255 {
+		t.Errorf("int('ff', base=16) = %v, %v, want 255", result, err)
+	}
+}
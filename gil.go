@@ -0,0 +1,67 @@
+package gp
+
+/*
+#include <Python.h>
+*/
+import "C"
+
+import "runtime"
+
+// GILState is a GIL acquisition made by AcquireGIL.
+type GILState struct {
+	state C.PyGILState_STATE
+}
+
+// AcquireGIL makes the calling goroutine ready to call into Python: it locks
+// the goroutine to its OS thread and acquires the GIL, creating a Python
+// thread state for the thread if it has none. Calls may be nested. Every
+// call must be paired with Release on the same goroutine.
+//
+// The thread that called Initialize holds the GIL until it calls ReleaseGIL,
+// so other goroutines can only acquire it after that.
+func AcquireGIL() GILState {
+	runtime.LockOSThread()
+	s := GILState{C.PyGILState_Ensure()}
+	flushIfOnAcquire()
+	return s
+}
+
+// Release releases the GIL acquired by AcquireGIL and unlocks the goroutine
+// from its OS thread.
+func (s GILState) Release() {
+	flushBeforeRelease()
+	C.PyGILState_Release(s.state)
+	runtime.UnlockOSThread()
+}
+
+// WithGIL runs fn with the GIL held. It can be called from any goroutine.
+func WithGIL(fn func()) {
+	s := AcquireGIL()
+	defer s.Release()
+	fn()
+}
+
+// ThreadState is the Python thread state saved by ReleaseGIL.
+type ThreadState struct {
+	ts *C.PyThreadState
+}
+
+// ReleaseGIL releases the GIL held by the calling thread so that other
+// Python threads and goroutines using AcquireGIL can run, e.g. while a Go
+// function called from Python does long work that doesn't touch Python
+// objects. Call Restore on the same goroutine before using Python again:
+//
+//	s := gp.ReleaseGIL()
+//	defer s.Restore()
+func ReleaseGIL() ThreadState {
+	runtime.LockOSThread()
+	flushBeforeRelease()
+	return ThreadState{C.PyEval_SaveThread()}
+}
+
+// Restore re-acquires the GIL released by ReleaseGIL.
+func (s ThreadState) Restore() {
+	C.PyEval_RestoreThread(s.ts)
+	flushIfOnAcquire()
+	runtime.UnlockOSThread()
+}
@@ -0,0 +1,180 @@
+package gp
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWithGILFromGoroutines(t *testing.T) {
+	setupTest(t)
+	if err := RunString("def square(x):\n    return x * x"); err != nil {
+		t.Fatal(err)
+	}
+
+	s := ReleaseGIL()
+	results := make([]int, 16)
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			WithGIL(func() {
+				// Nested acquisition is allowed.
+				WithGIL(func() {
+					square := MainModule().AttrFunc("square")
+					results[i] = square.Call(i).AsLong().Int()
+				})
+			})
+		}(i)
+	}
+	wg.Wait()
+	s.Restore()
+
+	for i, r := range results {
+		if r != i*i {
+			t.Errorf("square(%d) = %d, want %d", i, r, i*i)
+		}
+	}
+}
+
+func TestReleaseGILInExportedFunction(t *testing.T) {
+	setupTest(t)
+	m := MainModule()
+	m.AddMethod("go_sleep", func(ms int) {
+		s := ReleaseGIL()
+		defer s.Restore()
+		time.Sleep(time.Duration(ms) * time.Millisecond)
+	}, "")
+
+	code := `
+import threading
+
+ticks = 0
+done = False
+
+def spin():
+    global ticks
+    while not done:
+        ticks += 1
+
+t = threading.Thread(target=spin)
+t.start()
+before = ticks
+go_sleep(100)
+during = ticks
+done = True
+t.join()
+assert during > before, (before, during)
+`
+	if err := RunString(code); err != nil {
+		t.Fatal(err)
+	}
+}
+
+type gilWorker struct {
+	barrier *sync.WaitGroup
+}
+
+func (w *gilWorker) Meet() bool {
+	return meet(w.barrier)
+}
+
+// meet waits until every caller has arrived, which only succeeds if the
+// callers run concurrently.
+func meet(wg *sync.WaitGroup) bool {
+	wg.Done()
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(5 * time.Second):
+		return false
+	}
+}
+
+func TestNoGILMethod(t *testing.T) {
+	setupTest(t)
+	m := MainModule()
+
+	var fnBarrier sync.WaitGroup
+	fnBarrier.Add(2)
+	m.AddMethod("meet", func() bool { return meet(&fnBarrier) }, "", NoGIL())
+
+	var typeBarrier sync.WaitGroup
+	typeBarrier.Add(2)
+	m.AddType(gilWorker{}, func() *gilWorker {
+		return &gilWorker{barrier: &typeBarrier}
+	}, "GILWorker", "", NoGIL())
+
+	code := `
+from concurrent.futures import ThreadPoolExecutor
+
+with ThreadPoolExecutor(2) as pool:
+    assert list(pool.map(lambda _: meet(), range(2))) == [True, True]
+
+worker = GILWorker()
+with ThreadPoolExecutor(2) as pool:
+    assert list(pool.map(lambda _: worker.meet(), range(2))) == [True, True]
+`
+	if err := RunString(code); err != nil {
+		t.Fatal(err)
+	}
+}
+
+type gilPoint struct {
+	X, Y int
+}
+
+func TestConcurrentExtensionCalls(t *testing.T) {
+	setupTest(t)
+	m := MainModule()
+	m.AddType(gilPoint{}, nil, "GILPoint", "")
+	m.AddMethod("make_point", func(x int) *gilPoint {
+		return &gilPoint{X: x, Y: x * 2}
+	}, "")
+	m.AddMethod("sum_shared", func() int {
+		sum := 0
+		shared := MainModule().Dict().GetString("shared").AsDict()
+		shared.Items()(func(_, v Object) bool {
+			sum += v.AsLong().Int()
+			return true
+		})
+		return sum
+	}, "")
+	if err := RunString(`
+shared = {i: i for i in range(100)}
+
+def work(i):
+    p = make_point(i)
+    assert p.y == 2 * i
+    shared[100 + i] = 0
+    return sum_shared()
+`); err != nil {
+		t.Fatal(err)
+	}
+
+	s := ReleaseGIL()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				WithGIL(func() {
+					work := MainModule().AttrFunc("work")
+					if got := work.Call(i).AsLong().Int(); got != 4950 {
+						t.Errorf("work(%d) = %d, want 4950", i, got)
+					}
+					RegisterError(errRetry, ValueError)
+				})
+			}
+		}(i)
+	}
+	wg.Wait()
+	s.Restore()
+}
@@ -39,29 +39,32 @@ func (l *holderList) Remove(holder *objectHolder) {
 
 // ----------------------------------------------------------------------------
 
-const maxPyObjects = 128
-
 type decRefList struct {
 	objects []*C.PyObject
 	mu      sync.Mutex
+	n       int32 // len(objects), readable without mu
 }
 
 func (l *decRefList) add(obj *C.PyObject) {
 	l.mu.Lock()
 	l.objects = append(l.objects, obj)
+	atomic.StoreInt32(&l.n, int32(len(l.objects)))
 	l.mu.Unlock()
 }
 
 func (l *decRefList) len() int {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-	return len(l.objects)
+	return int(atomic.LoadInt32(&l.n))
 }
 
 func (l *decRefList) decRefAll() {
 	l.mu.Lock()
 	list := l.objects
-	l.objects = make([]*C.PyObject, 0, maxPyObjects*2)
+	if len(list) == 0 {
+		l.mu.Unlock()
+		return
+	}
+	l.objects = make([]*C.PyObject, 0, len(list))
+	atomic.StoreInt32(&l.n, 0)
 	l.mu.Unlock()
 
 	for _, obj := range list {
@@ -72,20 +75,130 @@ func (l *decRefList) decRefAll() {
 // ----------------------------------------------------------------------------
 
 type globalData struct {
-	typeMetas    map[*C.PyObject]*typeMeta
-	pyTypes      map[reflect.Type]*C.PyObject
-	holders      holderList
-	decRefList   decRefList
-	finished     int32
-	alwaysDecRef bool
+	// mu guards typeMetas, pyTypes, holders and errorMappings, which are
+	// also accessed concurrently on free-threaded builds.
+	mu sync.RWMutex
+
+	typeMetas     map[*C.PyObject]*typeMeta
+	pyTypes       map[reflect.Type]*C.PyObject
+	holders       holderList
+	decRefList    decRefList
+	finished      int32
+	goPanicType   Object
+	cancelledType Object
+
+	errorMappings []errorMapping
+
+	// Active scopes by thread ident, guarded by mu. numScopes lets
+	// newObject skip the lookup when there are none.
+	scopes    map[C.ulong]*Scope
+	numScopes int32
+
+	// Created by the first InstallLoggingHandler call.
+	logHandler *logHandler
+
+	// Functions set by SetProfiler and SetTracer.
+	profiler ProfileFunc
+	tracer   ProfileFunc
+
+	// The datetime C API of the interpreter once loaded, see importDateTime,
+	// and the module name looked up until then.
+	dateTimeAPI  unsafe.Pointer
+	dateTimeName Object
+
+	// Object accounting, see Stats.
+	liveObjects int64
+	tracked     trackedObjects
 }
 
 var (
 	global *globalData
+
+	// Per-interpreter data of the sub-interpreters created by NewInterpreter.
+	// numInterps lets getGlobalData skip the lookup when there are none.
+	interps    = make(map[*C.PyInterpreterState]*globalData)
+	interpsMu  sync.RWMutex
+	numInterps int32
 )
 
+// getGlobalData returns the data of the current interpreter. The calling
+// thread must hold the GIL.
 func getGlobalData() *globalData {
-	return global
+	if atomic.LoadInt32(&numInterps) == 0 {
+		return global
+	}
+	interpsMu.RLock()
+	gd, ok := interps[C.PyInterpreterState_Get()]
+	interpsMu.RUnlock()
+	if !ok {
+		return global
+	}
+	return gd
+}
+
+func (gd *globalData) typeMeta(typ *C.PyObject) *typeMeta {
+	gd.mu.RLock()
+	defer gd.mu.RUnlock()
+	return gd.typeMetas[typ]
+}
+
+// slot returns the method, getter or setter id of the type or module key.
+func (gd *globalData) slot(key *C.PyObject, id uint) (*typeMeta, *slotMeta) {
+	gd.mu.RLock()
+	defer gd.mu.RUnlock()
+	meta := gd.typeMetas[key]
+	if meta == nil {
+		return nil, nil
+	}
+	return meta, meta.methods[id]
+}
+
+func (gd *globalData) pyType(ty reflect.Type) (*C.PyObject, bool) {
+	gd.mu.RLock()
+	defer gd.mu.RUnlock()
+	typ, ok := gd.pyTypes[ty]
+	return typ, ok
+}
+
+func (gd *globalData) addType(typeObj *C.PyObject, meta *typeMeta) {
+	gd.mu.Lock()
+	defer gd.mu.Unlock()
+	gd.typeMetas[typeObj] = meta
+	gd.pyTypes[meta.typ] = typeObj
+}
+
+// addModuleMethod registers a function of the module mod and returns its id.
+func (gd *globalData) addModuleMethod(mod *C.PyObject, newMethod func(id uint) *slotMeta) uint {
+	gd.mu.Lock()
+	defer gd.mu.Unlock()
+	meta, ok := gd.typeMetas[mod]
+	if !ok {
+		meta = &typeMeta{
+			methods: make(map[uint]*slotMeta),
+		}
+		gd.typeMetas[mod] = meta
+	}
+	id := uint(len(meta.methods))
+	meta.methods[id] = newMethod(id)
+	return id
+}
+
+func (gd *globalData) addHolder(holder *objectHolder) {
+	gd.mu.Lock()
+	gd.holders.PushFront(holder)
+	gd.mu.Unlock()
+}
+
+func (gd *globalData) removeHolder(holder *objectHolder) {
+	gd.mu.Lock()
+	gd.holders.Remove(holder)
+	gd.mu.Unlock()
+}
+
+func (gd *globalData) addErrorMapping(m errorMapping) {
+	gd.mu.Lock()
+	gd.errorMappings = append(gd.errorMappings, m)
+	gd.mu.Unlock()
 }
 
 func (gd *globalData) addDecRef(obj *C.PyObject) {
@@ -96,26 +209,43 @@ func (gd *globalData) addDecRef(obj *C.PyObject) {
 }
 
 func (gd *globalData) decRefObjectsIfNeeded() {
-	if gd.alwaysDecRef || gd.decRefList.len() >= maxPyObjects {
+	if n := gd.decRefList.len(); n > 0 && n >= int(atomic.LoadInt32(&decRefBatchSize)) {
 		gd.decRefList.decRefAll()
 	}
 }
 
 // ----------------------------------------------------------------------------
 
-func initGlobal() {
-	global = &globalData{
+func newGlobalData() *globalData {
+	return &globalData{
 		typeMetas: make(map[*C.PyObject]*typeMeta),
 		pyTypes:   make(map[reflect.Type]*C.PyObject),
+		scopes:    make(map[C.ulong]*Scope),
 	}
 }
 
-func markFinished() {
-	atomic.StoreInt32(&global.finished, 1)
+// init creates the objects owned by gd. gd must already be returned by
+// getGlobalData so that they are released in the right interpreter.
+func (gd *globalData) init() {
+	// The exceptions live in the importable _gp module, so Python code can
+	// catch them with `from _gp import GoPanic`.
+	cname := AllocCStr("_gp")
+	m := Module{newObjectRef(C.PyImport_AddModule(cname))}
+	C.free(unsafe.Pointer(cname))
+	gd.goPanicType = m.AddException("GoPanic", RuntimeError,
+		"Raised when an exported Go function panics.")
+	gd.cancelledType = m.AddException("ContextCancelled", BaseException,
+		"Raised in Python code run with a Go context when the context is done.")
+	gd.dateTimeName = MakeStr("datetime").Object
+	gd.registerDefaultErrors()
 }
 
-func cleanupGlobal() {
-	for _, meta := range global.typeMetas {
+func (gd *globalData) markFinished() {
+	atomic.StoreInt32(&gd.finished, 1)
+}
+
+func (gd *globalData) cleanup() {
+	for _, meta := range gd.typeMetas {
 		for _, method := range meta.methods {
 			def := method.def
 			if def != nil {
@@ -125,5 +255,18 @@ func cleanupGlobal() {
 			}
 		}
 	}
+}
+
+func initGlobal() {
+	global = newGlobalData()
+	global.init()
+}
+
+func markFinished() {
+	global.markFinished()
+}
+
+func cleanupGlobal() {
+	global.cleanup()
 	global = nil
 }
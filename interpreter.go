@@ -0,0 +1,215 @@
+package gp
+
+/*
+#include <Python.h>
+
+static PyThreadState *newInterpreter(int ownGIL, const char **errmsg) {
+#if PY_VERSION_HEX >= 0x030C0000
+	PyInterpreterConfig config = {
+		.use_main_obmalloc = 1,
+		.allow_fork = 1,
+		.allow_exec = 1,
+		.allow_threads = 1,
+		.allow_daemon_threads = 1,
+		.check_multi_interp_extensions = 0,
+		.gil = PyInterpreterConfig_SHARED_GIL,
+	};
+	if (ownGIL) {
+		PyInterpreterConfig isolated = {
+			.use_main_obmalloc = 0,
+			.allow_fork = 0,
+			.allow_exec = 0,
+			.allow_threads = 1,
+			.allow_daemon_threads = 0,
+			.check_multi_interp_extensions = 1,
+			.gil = PyInterpreterConfig_OWN_GIL,
+		};
+		config = isolated;
+	}
+	PyThreadState *ts = NULL;
+	PyStatus status = Py_NewInterpreterFromConfig(&ts, &config);
+	if (PyStatus_Exception(status)) {
+		*errmsg = status.err_msg ? status.err_msg : "failed to create interpreter";
+		return NULL;
+	}
+	return ts;
+#else
+	if (ownGIL) {
+		*errmsg = "a per-interpreter GIL requires Python 3.12 or later";
+		return NULL;
+	}
+	PyThreadState *ts = Py_NewInterpreter();
+	if (ts == NULL) {
+		*errmsg = "failed to create interpreter";
+	}
+	return ts;
+#endif
+}
+
+// endInterpreter ends the interpreter of the current thread state and returns
+// with no thread state and no GIL held.
+static void endInterpreter(PyThreadState *ts) {
+	Py_EndInterpreter(ts);
+#if PY_VERSION_HEX < 0x030C0000
+	// Before 3.12 Py_EndInterpreter returns with the GIL still held. Release
+	// it through a temporary thread state, PyEval_ReleaseLock needs one too.
+	PyThreadState *tmp = PyThreadState_New(PyInterpreterState_Main());
+	PyThreadState_Swap(tmp);
+	PyThreadState_Clear(tmp);
+	PyThreadState_DeleteCurrent();
+#endif
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"runtime"
+	"sync/atomic"
+)
+
+// InterpreterConfig configures a sub-interpreter created by NewInterpreter.
+type InterpreterConfig struct {
+	// OwnGIL gives the interpreter its own GIL, so that it runs in parallel
+	// with the main interpreter and other sub-interpreters. Such an
+	// interpreter only imports extension modules that support
+	// sub-interpreters and can't fork or exec. Requires Python 3.12 or later.
+	OwnGIL bool
+}
+
+// Interpreter is a Python sub-interpreter. It has its own modules, including
+// __main__, and its own registry of Go types added with Module.AddType,
+// exceptions and error mappings.
+//
+// Objects must not be shared between interpreters: objects created inside
+// Run belong to the interpreter and must only be used inside its Run.
+type Interpreter struct {
+	interp *C.PyInterpreterState
+	ts     *C.PyThreadState // detached initial thread state
+	data   *globalData
+	closed bool
+}
+
+// ErrInterpreterClosed is returned when using a closed Interpreter.
+var ErrInterpreterClosed = errors.New("gp: interpreter is closed")
+
+// NewInterpreter creates a sub-interpreter. It must be called with the GIL of
+// the main interpreter held, not from inside Interpreter.Run.
+func NewInterpreter(config InterpreterConfig) (*Interpreter, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	prev := C.PyThreadState_Get()
+	var errmsg *C.char
+	var ownGIL C.int
+	if config.OwnGIL {
+		ownGIL = 1
+	}
+	ts := C.newInterpreter(ownGIL, &errmsg)
+	if ts == nil {
+		C.PyThreadState_Swap(prev)
+		return nil, errors.New(C.GoString(errmsg))
+	}
+
+	i := &Interpreter{
+		interp: C.PyThreadState_GetInterpreter(ts),
+		ts:     ts,
+		data:   newGlobalData(),
+	}
+	i.register()
+	i.data.init()
+
+	// Run creates a thread state for the calling thread each time. The
+	// initial one is kept detached, as Python 3.11 can't create thread states
+	// again once an interpreter had none.
+	C.PyEval_SaveThread()
+	C.PyEval_RestoreThread(prev)
+	return i, nil
+}
+
+func (i *Interpreter) register() {
+	interpsMu.Lock()
+	interps[i.interp] = i.data
+	interpsMu.Unlock()
+	atomic.AddInt32(&numInterps, 1)
+}
+
+func (i *Interpreter) unregister() {
+	interpsMu.Lock()
+	delete(interps, i.interp)
+	interpsMu.Unlock()
+	atomic.AddInt32(&numInterps, -1)
+}
+
+// Run calls fn with the interpreter as the current interpreter and its GIL
+// held. It can be called from any goroutine, whether or not it holds the GIL
+// of another interpreter, which is released while fn runs. fn must not start
+// goroutines that call into Python.
+func (i *Interpreter) Run(fn func()) error {
+	if i.closed {
+		return ErrInterpreterClosed
+	}
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ts, prev := i.enter()
+	defer func() {
+		C.PyThreadState_Clear(ts)
+		C.PyThreadState_DeleteCurrent()
+		leave(prev)
+	}()
+	defer i.data.decRefObjectsIfNeeded()
+	fn()
+	return nil
+}
+
+// RunString runs Python code in the interpreter's __main__ module.
+func (i *Interpreter) RunString(code string) (err error) {
+	if runErr := i.Run(func() { err = RunString(code) }); runErr != nil {
+		return runErr
+	}
+	return err
+}
+
+// enter makes a new thread state of the interpreter current on the locked OS
+// thread. The thread state the thread had before, if any, is returned as prev
+// after releasing its GIL.
+func (i *Interpreter) enter() (ts, prev *C.PyThreadState) {
+	if C._PyThreadState_UncheckedGet() != nil {
+		prev = C.PyEval_SaveThread()
+	}
+	ts = C.PyThreadState_New(i.interp)
+	C.PyEval_RestoreThread(ts)
+	return ts, prev
+}
+
+// leave restores the thread state returned by enter once the thread has no
+// current thread state.
+func leave(prev *C.PyThreadState) {
+	if prev != nil {
+		C.PyEval_RestoreThread(prev)
+	}
+}
+
+// Close destroys the interpreter. Objects created in it must not be used
+// afterwards, and Close must not run concurrently with Run. All interpreters
+// must be closed before Finalize.
+func (i *Interpreter) Close() error {
+	if i.closed {
+		return ErrInterpreterClosed
+	}
+	i.closed = true
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ts, prev := i.enter()
+	i.data.decRefList.decRefAll()
+	i.data.markFinished()
+	C.PyThreadState_Clear(i.ts)
+	C.PyThreadState_Delete(i.ts)
+	C.endInterpreter(ts)
+	i.unregister()
+	i.data.cleanup()
+	leave(prev)
+	return nil
+}
@@ -0,0 +1,141 @@
+package gp
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+type tenantCounter struct {
+	N int
+}
+
+func (c *tenantCounter) Incr() int {
+	c.N++
+	return c.N
+}
+
+func TestInterpreterIsolation(t *testing.T) {
+	setupTest(t)
+	if err := RunString("shared = 'main'"); err != nil {
+		t.Fatal(err)
+	}
+
+	var subs []*Interpreter
+	for i := 0; i < 2; i++ {
+		sub, err := NewInterpreter(InterpreterConfig{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		subs = append(subs, sub)
+
+		name := fmt.Sprintf("tenant%d", i)
+		err = sub.Run(func() {
+			m := MainModule()
+			m.AddType(tenantCounter{}, nil, "Counter", "")
+			m.AddMethod("tenant", func() string { return name }, "")
+			m.AddMethod("fail", func() error { return errors.New("boom") }, "")
+			m.AddMethod("crash", func() { panic("crash") }, "")
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i, sub := range subs {
+		code := fmt.Sprintf(`
+assert "shared" not in globals()
+assert tenant() == "tenant%d"
+c = Counter()
+c.incr()
+assert c.incr() == 2
+try:
+    fail()
+    assert False, "expected exception"
+except RuntimeError as e:
+    assert str(e) == "boom"
+try:
+    crash()
+    assert False, "expected exception"
+except RuntimeError as e:
+    assert type(e).__name__ == "GoPanic"
+`, i)
+		if err := sub.RunString(code); err != nil {
+			t.Fatalf("tenant%d: %v", i, err)
+		}
+	}
+
+	// Types are registered per interpreter.
+	var types []Object
+	for _, sub := range subs {
+		if err := sub.Run(func() {
+			types = append(types, MainModule().Attr("Counter"))
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if types[0].cpyObj() == types[1].cpyObj() {
+		t.Error("interpreters should have distinct Counter types")
+	}
+	if err := RunString("assert 'Counter' not in globals()"); err != nil {
+		t.Error(err)
+	}
+
+	err := subs[0].RunString("raise KeyError('k')")
+	if !ErrorMatches(err, KeyError) {
+		t.Errorf("RunString() error = %v, want KeyError", err)
+	}
+
+	for _, sub := range subs {
+		if err := sub.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if err := sub.RunString("pass"); !errors.Is(err, ErrInterpreterClosed) {
+			t.Errorf("RunString() after Close = %v, want ErrInterpreterClosed", err)
+		}
+	}
+	if err := RunString("assert shared == 'main'"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestInterpreterFromGoroutines(t *testing.T) {
+	setupTest(t)
+	version := ImportModule("sys").Attr("version_info").AsTuple()
+	ownGIL := version.Get(0).AsLong().Int() == 3 && version.Get(1).AsLong().Int() >= 12
+
+	sub, err := NewInterpreter(InterpreterConfig{OwnGIL: ownGIL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ownGIL {
+		if _, err := NewInterpreter(InterpreterConfig{OwnGIL: true}); err == nil {
+			t.Error("OwnGIL should fail before Python 3.12")
+		}
+	}
+	if err := sub.RunString("total = 0"); err != nil {
+		t.Fatal(err)
+	}
+
+	s := ReleaseGIL()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := sub.RunString(fmt.Sprintf("total += %d", i)); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	s.Restore()
+
+	if err := sub.RunString("assert total == 28, total"); err != nil {
+		t.Error(err)
+	}
+	if err := sub.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
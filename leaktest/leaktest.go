@@ -0,0 +1,88 @@
+// Package leaktest checks that a test doesn't leak Python objects referenced
+// from Go.
+package leaktest
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	gp "github.com/gotray/go-python"
+)
+
+// Check records the current object counts and returns a function that fails
+// t if they grew, reporting where the leaked objects were created:
+//
+//	func TestSomething(t *testing.T) {
+//		defer leaktest.Check(t)()
+//		...
+//	}
+//
+// It counts Object wrappers, Go values held by Python objects and, on debug
+// builds of Python, sys.gettotalrefcount(). Garbage collection is retried
+// for a while before reporting, as finalizers run asynchronously. Both
+// functions must be called with the GIL held.
+func Check(t testing.TB) func() {
+	t.Helper()
+	prevTracking := gp.SetTracking(true)
+	before := settle()
+	return func() {
+		t.Helper()
+		defer gp.SetTracking(prevTracking)
+		var after gp.ObjectStats
+		for i := 0; i < 20; i++ {
+			after = settle()
+			if !grew(before, after) {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Errorf("leaktest: %s", report(before, after))
+	}
+}
+
+func settle() gp.ObjectStats {
+	runtime.GC()
+	runtime.GC()
+	gp.FlushDecRefs()
+	return gp.Stats()
+}
+
+func grew(before, after gp.ObjectStats) bool {
+	return after.Objects > before.Objects ||
+		after.Holders > before.Holders ||
+		after.TotalRefCount > before.TotalRefCount
+}
+
+func report(before, after gp.ObjectStats) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "objects %d -> %d, holders %d -> %d",
+		before.Objects, after.Objects, before.Holders, after.Holders)
+	if after.TotalRefCount >= 0 {
+		fmt.Fprintf(&b, ", total refcount %d -> %d", before.TotalRefCount, after.TotalRefCount)
+	}
+	writeDiff(&b, "objects by site", before.ObjectsBySite, after.ObjectsBySite)
+	writeDiff(&b, "objects by type", before.ObjectsByType, after.ObjectsByType)
+	writeDiff(&b, "holders by type", before.HoldersByType, after.HoldersByType)
+	return b.String()
+}
+
+func writeDiff(b *strings.Builder, title string, before, after map[string]int) {
+	var keys []string
+	for k, n := range after {
+		if n > before[k] {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		return
+	}
+	sort.Strings(keys)
+	fmt.Fprintf(b, "\n%s:", title)
+	for _, k := range keys {
+		fmt.Fprintf(b, "\n\t+%d %s", after[k]-before[k], k)
+	}
+}
@@ -0,0 +1,50 @@
+package leaktest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	gp "github.com/gotray/go-python"
+)
+
+// recorder captures the failure reported by Check.
+type recorder struct {
+	testing.TB
+	msg string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.msg = fmt.Sprintf(format, args...)
+}
+
+func TestCheck(t *testing.T) {
+	gp.Initialize()
+	defer gp.Finalize()
+
+	var leaked []gp.Object
+	r := &recorder{TB: t}
+	check := Check(r)
+	for i := 0; i < 3; i++ {
+		leaked = append(leaked, gp.MakeList(i).Object)
+	}
+	check()
+	if !strings.Contains(r.msg, "leaktest_test.go") || !strings.Contains(r.msg, "+3 list") {
+		t.Errorf("leak not reported with its site and type:\n%s", r.msg)
+	}
+
+	r = &recorder{TB: t}
+	check = Check(r)
+	for i := 0; i < 3; i++ {
+		gp.MakeList(i).Call("append", i)
+	}
+	for _, o := range leaked {
+		o.Close()
+	}
+	check()
+	if r.msg != "" {
+		t.Errorf("unexpected leak report:\n%s", r.msg)
+	}
+}
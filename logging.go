@@ -0,0 +1,175 @@
+package gp
+
+/*
+#include <Python.h>
+*/
+import "C"
+
+import (
+	"math"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Python logging levels.
+const (
+	pyLogDebug   = 10
+	pyLogInfo    = 20
+	pyLogWarning = 30
+	pyLogError   = 40
+)
+
+// InstallLoggingHandler adds a handler to the root Python logger that writes
+// the records of Python loggers to logger, replacing the one installed by a
+// previous call in the current interpreter.
+//
+// The record message becomes the entry message, the Python logger name is
+// appended to the zap logger name, and the file, line and function of the
+// record are the caller. The exception and stack info are added as the
+// "exception" and "stack" fields, and the attributes passed with `extra` as
+// fields of their own. CRITICAL records are logged at the error level. The
+// handler level is the lowest level enabled by logger; the levels of the
+// Python loggers, WARNING for the root logger by default, still decide which
+// records reach it.
+func InstallLoggingHandler(logger *zap.Logger) {
+	gd := getGlobalData()
+	if gd.logHandler == nil {
+		gd.logHandler = newLogHandler()
+	}
+	h := gd.logHandler
+	h.logger = logger
+
+	root := ImportModule("logging").AttrFunc("getLogger").Call()
+	handlers := root.Attr("handlers").AsList()
+	for i := handlers.Len() - 1; i >= 0; i-- {
+		if handler := handlers.GetItem(i); handler.Type().Equals(h.typ) {
+			root.Call("removeHandler", handler)
+		}
+	}
+
+	level := pyLogError
+	switch {
+	case logger.Core().Enabled(zapcore.DebugLevel):
+		level = pyLogDebug
+	case logger.Core().Enabled(zapcore.InfoLevel):
+		level = pyLogInfo
+	case logger.Core().Enabled(zapcore.WarnLevel):
+		level = pyLogWarning
+	}
+	root.Call("addHandler", Func{h.typ}.Call(level))
+}
+
+// logHandler is the handler class created by InstallLoggingHandler in an
+// interpreter, with the logger its instances write to.
+type logHandler struct {
+	typ       Object
+	formatter Object
+	// LogRecord attributes that aren't extra fields.
+	standard map[string]bool
+	logger   *zap.Logger
+}
+
+func newLogHandler() *logHandler {
+	// The handler outlives the call, which may run in a Scope.
+	logging := ImportModule("logging")
+	h := &logHandler{
+		formatter: Unscoped(logging.AttrFunc("Formatter").Call()),
+		standard:  map[string]bool{"message": true, "asctime": true},
+	}
+	dummy := logging.AttrFunc("makeLogRecord").Call(MakeDict(nil))
+	dummy.Attr("__dict__").AsDict().Items()(func(key, _ Object) bool {
+		h.standard[key.String()] = true
+		return true
+	})
+
+	emit := Unscoped(CreateModule("_gp").AddMethod("emit", func(record Object) {
+		h.emit(record)
+	}, "Writes a log record to a Go zap logger."))
+	h.typ = Unscoped(ImportModule("builtins").AttrFunc("type").Call(
+		"GoZapHandler",
+		MakeTuple(logging.Attr("Handler")),
+		MakeDict(map[any]any{"emit": emit, "__module__": "_gp"}),
+	))
+	return h
+}
+
+// emit writes record to the logger of h.
+func (h *logHandler) emit(record Object) {
+	logger := h.logger
+	if name := record.Attr("name").String(); name != "" && name != "root" {
+		logger = logger.Named(name)
+	}
+	level := zapLevel(record.Attr("levelno").AsLong().Int())
+	if !logger.Core().Enabled(level) {
+		return
+	}
+	var fields []zap.Field
+	msg, err := record.TryCall("getMessage")
+	if err != nil {
+		// Log the unformatted message, as the arguments don't match it.
+		msg = record.Attr("msg")
+		fields = append(fields, zap.NamedError("format_error", err))
+	}
+	ce := logger.Check(level, msg.String())
+	if ce == nil {
+		return
+	}
+	created := record.Attr("created").AsFloat().Float64()
+	sec, frac := math.Modf(created)
+	ce.Time = time.Unix(int64(sec), int64(frac*1e9))
+	ce.Caller = zapcore.EntryCaller{
+		Defined:  true,
+		File:     record.Attr("pathname").String(),
+		Line:     record.Attr("lineno").AsLong().Int(),
+		Function: record.Attr("funcName").String(),
+	}
+
+	if excInfo := record.Attr("exc_info"); !excInfo.IsNone() && excInfo.IsTuple() {
+		fields = append(fields, zap.String("exception", h.formatter.Call("formatException", excInfo).String()))
+	}
+	if stackInfo := record.Attr("stack_info"); !stackInfo.IsNone() {
+		fields = append(fields, zap.String("stack", stackInfo.String()))
+	}
+	record.Attr("__dict__").AsDict().Items()(func(key, value Object) bool {
+		if name := key.String(); !h.standard[name] {
+			fields = append(fields, zapField(name, value))
+		}
+		return true
+	})
+	ce.Write(fields...)
+}
+
+func zapLevel(levelno int) zapcore.Level {
+	switch {
+	case levelno < pyLogInfo:
+		return zapcore.DebugLevel
+	case levelno < pyLogWarning:
+		return zapcore.InfoLevel
+	case levelno < pyLogError:
+		return zapcore.WarnLevel
+	default:
+		return zapcore.ErrorLevel
+	}
+}
+
+// zapField converts the value of an extra attribute to a field, keeping
+// booleans, numbers and strings typed and using str() for other objects.
+func zapField(key string, value Object) zap.Field {
+	switch {
+	case value.IsBool():
+		return zap.Bool(key, value.AsBool().Bool())
+	case value.IsLong():
+		v := C.PyLong_AsLongLong(value.obj)
+		if C.PyErr_Occurred() == nil {
+			return zap.Int64(key, int64(v))
+		}
+		C.PyErr_Clear()
+	case value.IsFloat():
+		return zap.Float64(key, value.AsFloat().Float64())
+	case value.IsNone():
+		return zap.Skip()
+	}
+	return zap.String(key, value.String())
+}
@@ -0,0 +1,102 @@
+package gp
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestInstallLoggingHandler(t *testing.T) {
+	setupTest(t)
+	core, logs := observer.New(zapcore.InfoLevel)
+	InstallLoggingHandler(zap.New(core).Named("py"))
+	// Installing again replaces the handler.
+	InstallLoggingHandler(zap.New(core).Named("py"))
+	handlerType := getGlobalData().logHandler.typ
+	InstallLoggingHandler(zap.New(core).Named("py"))
+	if !getGlobalData().logHandler.typ.Equals(handlerType) {
+		t.Error("InstallLoggingHandler created another handler class")
+	}
+
+	code := `
+import logging
+root = logging.getLogger()
+assert root.level == logging.WARNING, root.level
+assert root.handlers[0].level == logging.INFO, root.handlers[0].level
+root.setLevel(logging.DEBUG)
+log = logging.getLogger("app.db")
+log.debug("hidden")
+log.info("connected to %s", "db1", extra={"attempt": 2, "ok": True, "ratio": 0.5, "host": "db1", "big": 2**80})
+logging.warning("from root")
+try:
+    1 / 0
+except ZeroDivisionError:
+    log.exception("query failed")
+log.critical("down", stack_info=True)
+log.error("bad %d format", "x")
+assert len(logging.getLogger().handlers) == 1, logging.getLogger().handlers
+`
+	if err := RunString(code); err != nil {
+		t.Fatal(err)
+	}
+
+	entries := logs.AllUntimed()
+	if len(entries) != 5 {
+		t.Fatalf("got %d entries, want 5: %v", len(entries), entries)
+	}
+
+	info := entries[0]
+	if info.Message != "connected to db1" || info.Level != zapcore.InfoLevel || info.LoggerName != "py.app.db" {
+		t.Errorf("info entry = %q %v %q", info.Message, info.Level, info.LoggerName)
+	}
+	if !info.Caller.Defined || info.Caller.Line != 9 || info.Caller.Function != "<module>" {
+		t.Errorf("info caller = %+v", info.Caller)
+	}
+	fields := info.ContextMap()
+	want := map[string]any{"attempt": int64(2), "ok": true, "ratio": 0.5, "host": "db1", "big": "1208925819614629174706176"}
+	for key, value := range want {
+		if fields[key] != value {
+			t.Errorf("field %s = %#v, want %#v", key, fields[key], value)
+		}
+	}
+	if len(fields) != len(want) {
+		t.Errorf("fields = %v, want %v", fields, want)
+	}
+
+	if root := entries[1]; root.Message != "from root" || root.Level != zapcore.WarnLevel || root.LoggerName != "py" {
+		t.Errorf("root entry = %q %v %q", root.Message, root.Level, root.LoggerName)
+	}
+	exc := entries[2]
+	if exc.Level != zapcore.ErrorLevel || !strings.Contains(exc.ContextMap()["exception"].(string), "ZeroDivisionError") {
+		t.Errorf("exception entry = %v %v", exc.Level, exc.ContextMap())
+	}
+	critical := entries[3]
+	if critical.Level != zapcore.ErrorLevel || !strings.Contains(critical.ContextMap()["stack"].(string), "Stack (most recent call last)") {
+		t.Errorf("critical entry = %v %v", critical.Level, critical.ContextMap())
+	}
+	if bad := entries[4]; bad.Message != "bad %d format" || bad.ContextMap()["format_error"] == nil {
+		t.Errorf("bad format entry = %q %v", bad.Message, bad.ContextMap())
+	}
+}
+
+func TestInstallLoggingHandlerInScope(t *testing.T) {
+	setupTest(t)
+	core, logs := observer.New(zapcore.InfoLevel)
+	// The handler class created in a scope outlives it.
+	WithScope(func(*Scope) {
+		InstallLoggingHandler(zap.New(core))
+	})
+	runtime.GC()
+	InstallLoggingHandler(zap.New(core))
+	if err := RunString("import logging\nlogging.warning('after the scope')"); err != nil {
+		t.Fatal(err)
+	}
+	entries := logs.AllUntimed()
+	if len(entries) != 1 || entries[0].Message != "after the scope" {
+		t.Errorf("entries = %v, want one \"after the scope\"", entries)
+	}
+}
@@ -4,7 +4,11 @@ package gp
 #include <Python.h>
 */
 import "C"
-import "unsafe"
+
+import (
+	"math/big"
+	"unsafe"
+)
 
 type Long struct {
 	Object
@@ -33,22 +37,70 @@ func LongFromUnicode(u Object, base int) Long {
 	return newLong(C.PyLong_FromUnicodeObject(u.cpyObj(), C.int(base)))
 }
 
+// LongFromBigInt returns the int of the value of b.
+func LongFromBigInt(b *big.Int) Long {
+	if b.IsInt64() {
+		return MakeLong(b.Int64())
+	}
+	return LongFromString(b.Text(16), 16)
+}
+
 func (l Long) Int() int {
 	return int(l.Int64())
 }
 
+// Int64 returns l as an int64, which is -1 if l doesn't fit. Int64E reports
+// the overflow and BigInt converts any int.
 func (l Long) Int64() int64 {
 	return int64(C.PyLong_AsLongLong(l.obj))
 }
 
+// Int64E returns l as an int64, or an OverflowError *PyError if l doesn't
+// fit.
+func (l Long) Int64E() (int64, error) {
+	var overflow C.int
+	v := int64(C.PyLong_AsLongLongAndOverflow(l.obj, &overflow))
+	if v == -1 && C.PyErr_Occurred() != nil {
+		return 0, FetchError()
+	}
+	if overflow != 0 {
+		return 0, NewError(OverflowError, "int out of range of int64")
+	}
+	return v, nil
+}
+
 func (l Long) Uint() uint {
 	return uint(l.Uint64())
 }
 
+// Uint64 returns l as a uint64, which is the maximum uint64 if l is negative
+// or doesn't fit. Uint64E reports the overflow.
 func (l Long) Uint64() uint64 {
 	return uint64(C.PyLong_AsUnsignedLongLong(l.obj))
 }
 
+// Uint64E returns l as a uint64, or an OverflowError *PyError if l is
+// negative or doesn't fit.
+func (l Long) Uint64E() (uint64, error) {
+	v := uint64(C.PyLong_AsUnsignedLongLong(l.obj))
+	if C.PyErr_Occurred() != nil {
+		return 0, FetchError()
+	}
+	return v, nil
+}
+
+// BigInt returns l as a big.Int of any size.
+func (l Long) BigInt() *big.Int {
+	var overflow C.int
+	if v := int64(C.PyLong_AsLongLongAndOverflow(l.obj, &overflow)); overflow == 0 {
+		return big.NewInt(v)
+	}
+	hex := newObject(C.PyNumber_ToBase(l.obj, 16)).String()
+	b, ok := new(big.Int).SetString(hex, 0)
+	check(ok, "invalid hex int "+hex)
+	return b
+}
+
 func (l Long) Uintptr() uintptr {
 	return uintptr(l.Int64())
 }
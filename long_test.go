@@ -2,6 +2,8 @@ package gp
 
 import (
 	"math"
+	"math/big"
+	"reflect"
 	"testing"
 )
 
@@ -136,3 +138,64 @@ func TestLongFromUnicode(t *testing.T) {
 		}
 	}
 }
+
+func TestLongBigInt(t *testing.T) {
+	setupTest(t)
+	p256, _ := new(big.Int).SetString("115792089210356248762697446949407573530086143415290314195533631308867097853951", 10)
+	tests := []*big.Int{
+		big.NewInt(0),
+		big.NewInt(-42),
+		big.NewInt(math.MaxInt64),
+		new(big.Int).Add(big.NewInt(math.MaxInt64), big.NewInt(1)),
+		new(big.Int).Neg(p256),
+		p256,
+	}
+	for _, b := range tests {
+		l := LongFromBigInt(b)
+		if got := l.String(); got != b.String() {
+			t.Errorf("LongFromBigInt(%v) = %s", b, got)
+		}
+		if got := l.BigInt(); got.Cmp(b) != 0 {
+			t.Errorf("LongFromBigInt(%v).BigInt() = %v", b, got)
+		}
+		if got, err := To[*big.Int](From(b)); err != nil || got.Cmp(b) != 0 {
+			t.Errorf("To[*big.Int](From(%v)) = %v, %v", b, got, err)
+		}
+		if got, err := To[big.Int](From(*b)); err != nil || got.Cmp(b) != 0 {
+			t.Errorf("To[big.Int](From(%v)) = %v, %v", b, &got, err)
+		}
+	}
+
+	if got, err := To[any](From(p256)); err != nil || !reflect.DeepEqual(got, p256) {
+		t.Errorf("To[any](p256) = %v, %v", got, err)
+	}
+	if _, err := To[*big.Int](MakeStr("1").Object); err == nil || err.Error() != "expected int, got str" {
+		t.Errorf("To[*big.Int](str) error = %v", err)
+	}
+	if !From((*big.Int)(nil)).IsNone() {
+		t.Error("From(nil *big.Int) is not None")
+	}
+}
+
+func TestLongOverflow(t *testing.T) {
+	setupTest(t)
+	huge := LongFromString("18446744073709551616", 10)
+	if _, err := huge.Int64E(); err == nil || !ErrorMatches(err, OverflowError) {
+		t.Errorf("Int64E() error = %v", err)
+	}
+	if _, err := huge.Uint64E(); err == nil || !ErrorMatches(err, OverflowError) {
+		t.Errorf("Uint64E() error = %v", err)
+	}
+	if _, err := MakeLong(-1).Uint64E(); err == nil || !ErrorMatches(err, OverflowError) {
+		t.Errorf("Uint64E(-1) error = %v", err)
+	}
+	if v, err := MakeLong(math.MinInt64).Int64E(); err != nil || v != math.MinInt64 {
+		t.Errorf("Int64E() = %d, %v", v, err)
+	}
+	if v, err := LongFromString("18446744073709551615", 10).Uint64E(); err != nil || v != math.MaxUint64 {
+		t.Errorf("Uint64E() = %d, %v", v, err)
+	}
+	if _, err := (Long{MakeStr("x").Object}).Int64E(); err == nil {
+		t.Error("Int64E(str) succeeded")
+	}
+}
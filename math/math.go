@@ -8,7 +8,7 @@ var math_ gp.Module
 
 func math() gp.Module {
 	if math_.Nil() {
-		math_ = gp.ImportModule("math")
+		math_ = gp.Unscoped(gp.ImportModule("math"))
 	}
 	return math_
 }
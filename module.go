@@ -15,10 +15,20 @@ func newModule(obj *cPyObject) Module {
 }
 
 func ImportModule(name string) Module {
+	return must(TryImportModule(name))
+}
+
+// TryImportModule is like ImportModule but returns the Python exception as a
+// *PyError instead of panicking, e.g. for optional dependencies.
+func TryImportModule(name string) (Module, error) {
 	cname := AllocCStr(name)
 	mod := C.PyImport_ImportModule(cname)
 	C.free(unsafe.Pointer(cname))
-	return newModule(mod)
+	o, err := tryObject(mod)
+	if err != nil {
+		return Module{}, err
+	}
+	return Module{o}, nil
 }
 
 func GetModule(name string) Module {
@@ -26,7 +36,7 @@ func GetModule(name string) Module {
 }
 
 func (m Module) Dict() Dict {
-	return newDict(C.PyModule_GetDict(m.obj))
+	return Dict{newObjectRef(C.PyModule_GetDict(m.obj))}
 }
 
 func (m Module) AddObject(name string, obj Object) int {
@@ -46,5 +56,5 @@ func CreateModule(name string) Module {
 }
 
 func GetModuleDict() Dict {
-	return newDict(C.PyImport_GetModuleDict())
+	return Dict{newObjectRef(C.PyImport_GetModuleDict())}
 }
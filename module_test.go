@@ -94,3 +94,22 @@ func TestGetModuleDict(t *testing.T) {
 		t.Error("Module dictionary doesn't contain imported module")
 	}
 }
+
+func TestTryImportModule(t *testing.T) {
+	setupTest(t)
+	mod, err := TryImportModule("json")
+	if err != nil || mod.Name() != "json" {
+		t.Fatalf("TryImportModule(json) = %v, %v", mod, err)
+	}
+
+	mod, err = TryImportModule("module_that_does_not_exist")
+	if err == nil {
+		t.Fatal("TryImportModule should fail for missing module")
+	}
+	if !mod.Nil() {
+		t.Error("TryImportModule should return a nil module on failure")
+	}
+	if !ErrorMatches(err, ImportModule("builtins").Attr("ModuleNotFoundError")) {
+		t.Errorf("TryImportModule error = %v, want ModuleNotFoundError", err)
+	}
+}
@@ -66,12 +66,32 @@ func newObject(obj *cPyObject) Object {
 	}
 	o := &pyObject{obj: obj, g: getGlobalData()}
 	runtime.SetFinalizer(o, func(o *pyObject) {
+		o.g.objectReleased(o)
 		o.g.addDecRef(o.obj)
 		runtime.SetFinalizer(o, nil)
 	})
+	o.g.objectCreated(o)
+	if s := o.g.currentScope(); s != nil {
+		s.objects[o] = struct{}{}
+	}
 	return Object{o}
 }
 
+// Close releases the reference held by o right away instead of when o is
+// garbage collected. o and its copies must not be used afterwards. Closing a
+// nil or closed object, or one not owned by Go such as the builtin exception
+// classes, does nothing.
+func (o Object) Close() {
+	p := o.pyObject
+	if p == nil || p.obj == nil || p.g == nil {
+		return
+	}
+	runtime.SetFinalizer(p, nil)
+	p.g.objectReleased(p)
+	C.Py_DecRef(p.obj)
+	p.obj = nil
+}
+
 func (o Object) Dir() List {
 	return o.Call("__dir__").AsList()
 }
@@ -80,11 +100,32 @@ func (o Object) Equals(other Objecter) bool {
 	return C.PyObject_RichCompareBool(o.obj, other.cpyObj(), C.Py_EQ) != 0
 }
 
+// tryObject wraps a new reference returned by the C API, turning a NULL
+// result into the pending Python exception.
+func tryObject(obj *cPyObject) (Object, error) {
+	if obj == nil {
+		if err := FetchError(); err != nil {
+			return Object{}, err
+		}
+		return Object{}, fmt.Errorf("python error: nil object without exception set")
+	}
+	return newObject(obj), nil
+}
+
 func (o Object) Attr(name string) Object {
+	return must(o.TryAttr(name))
+}
+
+// TryAttr is like Attr but returns the Python exception as a *PyError
+// instead of panicking.
+func (o Object) TryAttr(name string) (Object, error) {
+	// Apply a batch before o.obj is read. Loops that never release the GIL
+	// would otherwise never free anything.
+	getGlobalData().decRefObjectsIfNeeded()
 	cname := AllocCStr(name)
 	attr := C.PyObject_GetAttrString(o.obj, cname)
 	C.free(unsafe.Pointer(cname))
-	return newObject(attr)
+	return tryObject(attr)
 }
 
 func (o Object) AttrFloat(name string) Float {
@@ -131,6 +172,10 @@ func (o Object) SetAttr(name string, value any) {
 	C.free(unsafe.Pointer(cname))
 }
 
+func (o Object) IsNone() bool {
+	return C.Py_Is(o.obj, C.Py_None) != 0
+}
+
 func (o Object) IsLong() bool {
 	return C.Py_IS_TYPE(o.obj, &C.PyLong_Type) != 0
 }
@@ -167,12 +212,22 @@ func (o Object) IsDict() bool {
 	return C.Py_IS_TYPE(o.obj, &C.PyDict_Type) != 0
 }
 
+func (o Object) IsSet() bool {
+	return C.Py_IS_TYPE(o.obj, &C.PySet_Type) != 0
+}
+
+func (o Object) IsFrozenSet() bool {
+	return C.Py_IS_TYPE(o.obj, &C.PyFrozenSet_Type) != 0
+}
+
 func (o Object) IsFunc() bool {
 	return C.Py_IS_TYPE(o.obj, &C.PyFunction_Type) != 0 ||
 		C.Py_IS_TYPE(o.obj, &C.PyMethod_Type) != 0 ||
 		C.Py_IS_TYPE(o.obj, &C.PyCFunction_Type) != 0
 }
 
+// AsFloat and the other As methods cast o without checking its Python type.
+// Use As to check it.
 func (o Object) AsFloat() Float {
 	return cast[Float](o)
 }
@@ -209,6 +264,14 @@ func (o Object) AsTuple() Tuple {
 	return cast[Tuple](o)
 }
 
+func (o Object) AsSet() Set {
+	return cast[Set](o)
+}
+
+func (o Object) AsFrozenSet() FrozenSet {
+	return cast[FrozenSet](o)
+}
+
 func (o Object) AsFunc() Func {
 	return cast[Func](o)
 }
@@ -218,12 +281,22 @@ func (o Object) AsModule() Module {
 }
 
 func (o Object) Call(name string, args ...any) Object {
-	fn := cast[Func](o.Attr(name))
+	return must(o.TryCall(name, args...))
+}
+
+// TryCall is like Call but returns the Python exception as a *PyError
+// instead of panicking.
+func (o Object) TryCall(name string, args ...any) (Object, error) {
+	attr, err := o.TryAttr(name)
+	if err != nil {
+		return Object{}, err
+	}
+	fn := cast[Func](attr)
 	argsTuple, kwArgs := splitArgs(args...)
 	if kwArgs == nil {
-		return fn.CallObject(argsTuple)
+		return fn.tryCallObject(argsTuple)
 	} else {
-		return fn.CallObjectKw(argsTuple, kwArgs)
+		return fn.tryCallObjectKw(argsTuple, kwArgs)
 	}
 }
 
@@ -239,6 +312,13 @@ func (o Object) String() string {
 	return newStr(C.PyObject_Str(o.obj)).String()
 }
 
+// newRef returns the underlying object as a new reference, e.g. for handing
+// it back to Python from a C callback.
+func (o Object) newRef() *cPyObject {
+	C.Py_IncRef(o.obj)
+	return o.obj
+}
+
 func (o Object) cpyObj() *cPyObject {
 	if o.Nil() {
 		return nil
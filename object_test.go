@@ -3,7 +3,10 @@ package gp
 import (
 	"bytes"
 	"reflect"
+	"runtime"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestObjectCreation(t *testing.T) {
@@ -429,3 +432,103 @@ def test_func(a, b=10, c="default"):
 		}
 	}()
 }
+
+func TestObjectTryAttrAndCall(t *testing.T) {
+	setupTest(t)
+	builtins := ImportModule("builtins")
+	s := MakeStr("hello")
+
+	upper, err := s.TryAttr("upper")
+	if err != nil || upper.Nil() {
+		t.Fatalf("TryAttr(upper) = %v, %v", upper, err)
+	}
+	_, err = s.TryAttr("missing")
+	if !ErrorMatches(err, builtins.Attr("AttributeError")) {
+		t.Errorf("TryAttr(missing) error = %v, want AttributeError", err)
+	}
+
+	result, err := s.TryCall("upper")
+	if err != nil || result.String() != "HELLO" {
+		t.Errorf("TryCall(upper) = %v, %v, want HELLO", result, err)
+	}
+	_, err = s.TryCall("missing")
+	if !ErrorMatches(err, builtins.Attr("AttributeError")) {
+		t.Errorf("TryCall(missing) error = %v, want AttributeError", err)
+	}
+	_, err = s.TryCall("index", "z")
+	if !ErrorMatches(err, builtins.Attr("ValueError")) {
+		t.Errorf("TryCall(index) error = %v, want ValueError", err)
+	}
+
+	func() {
+		defer func() {
+			r := recover()
+			if err, ok := r.(*PyError); !ok || err.TypeName() != "AttributeError" {
+				t.Errorf("Attr(missing) panic = %v, want *PyError AttributeError", r)
+			}
+		}()
+		s.Attr("missing")
+	}()
+}
+
+// refCountDelta runs fn, waits until the Objects it dropped are garbage
+// collected and their DecRefs applied, and returns how much the reference
+// count of obj changed.
+func refCountDelta(t *testing.T, obj Object, fn func()) int {
+	t.Helper()
+	gd := getGlobalData()
+	live := int64(-1)
+	for n := atomic.LoadInt64(&gd.liveObjects); n != live; n = atomic.LoadInt64(&gd.liveObjects) {
+		live = n
+		runtime.GC()
+		time.Sleep(time.Millisecond)
+	}
+	FlushDecRefs()
+	before := obj.RefCount()
+	fn()
+	deadline := time.Now().Add(5 * time.Second)
+	for atomic.LoadInt64(&gd.liveObjects) > live {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d Objects still alive", atomic.LoadInt64(&gd.liveObjects)-live)
+		}
+		runtime.GC()
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(time.Millisecond)
+	FlushDecRefs()
+	return obj.RefCount() - before
+}
+
+func TestBorrowedReferenceOwnership(t *testing.T) {
+	setupTest(t)
+	const n = 100
+	m := MainModule()
+	list := MakeList(1, 2, 3).Object
+	value := MakeStr("value").Object
+	dict := MakeDict(map[any]any{"key": value})
+	tests := []struct {
+		name string
+		obj  Object
+		fn   func()
+	}{
+		{"From(Objecter)", list, func() { From(list) }},
+		{"None", None(), func() { None() }},
+		{"True", True().Object, func() { True() }},
+		{"False", False().Object, func() { False() }},
+		{"Module.Dict", m.Dict().Object, func() { m.Dict() }},
+		{"GetModuleDict", GetModuleDict().Object, func() { GetModuleDict() }},
+		{"Dict.Items", value, func() {
+			dict.Items()(func(k, v Object) bool { return true })
+		}},
+	}
+	for _, tt := range tests {
+		got := refCountDelta(t, tt.obj, func() {
+			for i := 0; i < n; i++ {
+				tt.fn()
+			}
+		})
+		if got != 0 {
+			t.Errorf("%s: refcount changed by %d after %d calls", tt.name, got, n)
+		}
+	}
+}
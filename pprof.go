@@ -0,0 +1,327 @@
+package gp
+
+/*
+#include <Python.h>
+*/
+import "C"
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unsafe"
+)
+
+// CPUProfileRate is the number of samples per second taken by
+// StartCPUProfile, the same as the Go CPU profiler.
+const CPUProfileRate = 100
+
+var (
+	cpuProfileMu sync.Mutex
+	cpuProfile   *cpuProfiler
+)
+
+type cpuProfiler struct {
+	w       io.Writer
+	builder *profileBuilder
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// StartCPUProfile samples the stacks of the Python threads of the main
+// interpreter until StopCPUProfile, which writes them to w in the pprof
+// format. The profile has the sample types of a Go CPU profile, so the tools
+// can merge them, as in `go tool pprof -proto go.pprof py.pprof > all.pprof`.
+//
+// A sample is taken when the sampler can acquire the GIL, which the running
+// Python code releases every few milliseconds. Time spent holding the GIL
+// outside Python code, in Go or C extensions, is attributed to the Python
+// frames of the caller.
+func StartCPUProfile(w io.Writer) error {
+	cpuProfileMu.Lock()
+	defer cpuProfileMu.Unlock()
+	if cpuProfile != nil {
+		return errors.New("gp: cpu profiling already in use")
+	}
+	p := &cpuProfiler{
+		w:       w,
+		builder: newProfileBuilder(time.Second / CPUProfileRate),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	cpuProfile = p
+	go p.run()
+	return nil
+}
+
+// StopCPUProfile stops the profile started by StartCPUProfile, if any, and
+// writes it. It must be called before Finalize.
+func StopCPUProfile() error {
+	cpuProfileMu.Lock()
+	p := cpuProfile
+	cpuProfile = nil
+	cpuProfileMu.Unlock()
+	if p == nil {
+		return nil
+	}
+
+	close(p.stop)
+	if C.PyGILState_Check() != 0 {
+		// The sampler may be waiting for the GIL.
+		s := ReleaseGIL()
+		<-p.done
+		s.Restore()
+	} else {
+		<-p.done
+	}
+	return p.builder.write(p.w)
+}
+
+func (p *cpuProfiler) run() {
+	defer close(p.done)
+	ticker := time.NewTicker(p.builder.period)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			withGILInBackground(p.sample, nil)
+		case <-p.stop:
+			return
+		}
+	}
+}
+
+// sample adds the stacks of all threads, except the sampler's which runs no
+// Python code.
+func (p *cpuProfiler) sample() {
+	frames := ImportModule("sys").AttrFunc("_current_frames").Call().AsDict()
+	frames.Items()(func(_, frame Object) bool {
+		var stack []uint64
+		for f := (Frame{frame: (*C.PyFrameObject)(unsafe.Pointer(frame.obj)), ref: frame}); !f.Nil(); f = f.Back() {
+			stack = append(stack, p.builder.location(f))
+		}
+		p.builder.add(stack)
+		return true
+	})
+}
+
+// ----------------------------------------------------------------------------
+
+type profileFunction struct {
+	name, filename string
+	startLine      int
+}
+
+type profileLocation struct {
+	function uint64
+	line     int
+}
+
+type profileSample struct {
+	locations []uint64
+	count     int64
+}
+
+// profileBuilder builds a profile in the pprof format, described in
+// https://github.com/google/pprof/blob/main/proto/profile.proto.
+type profileBuilder struct {
+	start  time.Time
+	period time.Duration
+
+	strings     []string
+	stringIDs   map[string]int64
+	functions   []profileFunction
+	functionIDs map[profileFunction]uint64
+	locations   []profileLocation
+	locationIDs map[profileLocation]uint64
+	samples     []*profileSample
+	sampleIDs   map[string]*profileSample
+}
+
+func newProfileBuilder(period time.Duration) *profileBuilder {
+	return &profileBuilder{
+		start:       time.Now(),
+		period:      period,
+		strings:     []string{""},
+		stringIDs:   map[string]int64{"": 0},
+		functionIDs: make(map[profileFunction]uint64),
+		locationIDs: make(map[profileLocation]uint64),
+		sampleIDs:   make(map[string]*profileSample),
+	}
+}
+
+func (b *profileBuilder) location(f Frame) uint64 {
+	fn := profileFunction{name: pprofFunctionName(f.Function()), filename: f.Filename(), startLine: f.FirstLine()}
+	fnID, ok := b.functionIDs[fn]
+	if !ok {
+		b.functions = append(b.functions, fn)
+		fnID = uint64(len(b.functions))
+		b.functionIDs[fn] = fnID
+	}
+	loc := profileLocation{function: fnID, line: f.Line()}
+	id, ok := b.locationIDs[loc]
+	if !ok {
+		b.locations = append(b.locations, loc)
+		id = uint64(len(b.locations))
+		b.locationIDs[loc] = id
+	}
+	return id
+}
+
+// pprofFunctionName spells a trailing <module>, <lambda> or <listcomp> of a
+// function name as [module], [lambda] or [listcomp], as pprof drops trailing
+// angle brackets like C++ template arguments.
+func pprofFunctionName(name string) string {
+	if !strings.HasSuffix(name, ">") {
+		return name
+	}
+	i := strings.LastIndexByte(name, '<')
+	if i < 0 {
+		return name
+	}
+	return name[:i] + "[" + name[i+1:len(name)-1] + "]"
+}
+
+// add counts a sample of stack, which starts with the innermost frame.
+func (b *profileBuilder) add(stack []uint64) {
+	var key strings.Builder
+	for _, id := range stack {
+		key.WriteString(strconv.FormatUint(id, 36))
+		key.WriteByte(' ')
+	}
+	s, ok := b.sampleIDs[key.String()]
+	if !ok {
+		s = &profileSample{locations: stack}
+		b.samples = append(b.samples, s)
+		b.sampleIDs[key.String()] = s
+	}
+	s.count++
+}
+
+func (b *profileBuilder) stringID(s string) int64 {
+	id, ok := b.stringIDs[s]
+	if !ok {
+		id = int64(len(b.strings))
+		b.strings = append(b.strings, s)
+		b.stringIDs[s] = id
+	}
+	return id
+}
+
+// Field numbers of the Profile message.
+const (
+	pprofSampleType    = 1
+	pprofSample        = 2
+	pprofLocation      = 4
+	pprofFunction      = 5
+	pprofStringTable   = 6
+	pprofTimeNanos     = 9
+	pprofDurationNanos = 10
+	pprofPeriodType    = 11
+	pprofPeriod        = 12
+)
+
+func (b *profileBuilder) write(w io.Writer) error {
+	var p protoBuffer
+	valueType := func(field int, typ, unit string) {
+		var m protoBuffer
+		m.int64(1, b.stringID(typ))
+		m.int64(2, b.stringID(unit))
+		p.bytes(field, m.data)
+	}
+	valueType(pprofSampleType, "samples", "count")
+	valueType(pprofSampleType, "cpu", "nanoseconds")
+	for _, s := range b.samples {
+		var m protoBuffer
+		m.packedUint64(1, s.locations)
+		m.packedInt64(2, []int64{s.count, s.count * int64(b.period)})
+		p.bytes(pprofSample, m.data)
+	}
+	for i, loc := range b.locations {
+		var line, m protoBuffer
+		line.uint64(1, loc.function)
+		line.int64(2, int64(loc.line))
+		m.uint64(1, uint64(i+1))
+		m.bytes(4, line.data)
+		p.bytes(pprofLocation, m.data)
+	}
+	for i, fn := range b.functions {
+		var m protoBuffer
+		m.uint64(1, uint64(i+1))
+		m.int64(2, b.stringID(fn.name))
+		m.int64(3, b.stringID(fn.name))
+		m.int64(4, b.stringID(fn.filename))
+		m.int64(5, int64(fn.startLine))
+		p.bytes(pprofFunction, m.data)
+	}
+	p.int64(pprofTimeNanos, b.start.UnixNano())
+	p.int64(pprofDurationNanos, int64(time.Since(b.start)))
+	valueType(pprofPeriodType, "cpu", "nanoseconds")
+	p.int64(pprofPeriod, int64(b.period))
+	// Strings are interned above, so the table goes last.
+	for _, s := range b.strings {
+		p.string(pprofStringTable, s)
+	}
+
+	zw := gzip.NewWriter(w)
+	if _, err := zw.Write(p.data); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// protoBuffer encodes protocol buffer fields.
+type protoBuffer struct {
+	data []byte
+}
+
+func (b *protoBuffer) varint(v uint64) {
+	for v >= 0x80 {
+		b.data = append(b.data, byte(v)|0x80)
+		v >>= 7
+	}
+	b.data = append(b.data, byte(v))
+}
+
+func (b *protoBuffer) uint64(field int, v uint64) {
+	if v != 0 {
+		b.varint(uint64(field) << 3)
+		b.varint(v)
+	}
+}
+
+func (b *protoBuffer) int64(field int, v int64) {
+	b.uint64(field, uint64(v))
+}
+
+func (b *protoBuffer) bytes(field int, data []byte) {
+	b.varint(uint64(field)<<3 | 2)
+	b.varint(uint64(len(data)))
+	b.data = append(b.data, data...)
+}
+
+func (b *protoBuffer) string(field int, s string) {
+	b.varint(uint64(field)<<3 | 2)
+	b.varint(uint64(len(s)))
+	b.data = append(b.data, s...)
+}
+
+func (b *protoBuffer) packedUint64(field int, values []uint64) {
+	var m protoBuffer
+	for _, v := range values {
+		m.varint(v)
+	}
+	b.bytes(field, m.data)
+}
+
+func (b *protoBuffer) packedInt64(field int, values []int64) {
+	var m protoBuffer
+	for _, v := range values {
+		m.varint(uint64(v))
+	}
+	b.bytes(field, m.data)
+}
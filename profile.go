@@ -0,0 +1,227 @@
+package gp
+
+/*
+#include <Python.h>
+#include <frameobject.h>
+
+extern int goProfileFunc(PyFrameObject *frame, int what, PyObject *arg);
+extern int goTraceFunc(PyFrameObject *frame, int what, PyObject *arg);
+
+static int profileFunc(PyObject *obj, PyFrameObject *frame, int what, PyObject *arg) {
+	return goProfileFunc(frame, what, arg);
+}
+
+static int traceFunc(PyObject *obj, PyFrameObject *frame, int what, PyObject *arg) {
+	return goTraceFunc(frame, what, arg);
+}
+
+static void setProfile(int enable) {
+	PyEval_SetProfile(enable ? profileFunc : NULL, NULL);
+}
+
+static void setTrace(int enable) {
+	PyEval_SetTrace(enable ? traceFunc : NULL, NULL);
+}
+
+static PyObject *frameFilename(PyFrameObject *frame) {
+	PyCodeObject *code = PyFrame_GetCode(frame);
+	PyObject *name = code->co_filename;
+	Py_INCREF(name);
+	Py_DECREF(code);
+	return name;
+}
+
+static PyObject *frameFunction(PyFrameObject *frame) {
+	PyCodeObject *code = PyFrame_GetCode(frame);
+#if PY_VERSION_HEX >= 0x030B0000
+	PyObject *name = code->co_qualname;
+#else
+	PyObject *name = code->co_name;
+#endif
+	Py_INCREF(name);
+	Py_DECREF(code);
+	return name;
+}
+
+static int frameFirstLine(PyFrameObject *frame) {
+	PyCodeObject *code = PyFrame_GetCode(frame);
+	int line = code->co_firstlineno;
+	Py_DECREF(code);
+	return line;
+}
+
+// frameLocals returns a dict of the frame's locals. From Python 3.13 on
+// PyFrame_GetLocals returns a write-through proxy for function frames.
+static PyObject *frameLocals(PyFrameObject *frame) {
+	PyObject *locals = PyFrame_GetLocals(frame);
+	if (locals == NULL || PyDict_Check(locals)) {
+		return locals;
+	}
+	PyObject *dict = PyDict_New();
+	if (dict != NULL && PyDict_Update(dict, locals) < 0) {
+		Py_CLEAR(dict);
+	}
+	Py_DECREF(locals);
+	return dict;
+}
+*/
+import "C"
+
+import "unsafe"
+
+// Event is the kind of event passed to a profiler or tracer function.
+type Event int
+
+const (
+	EventCall       Event = C.PyTrace_CALL
+	EventException  Event = C.PyTrace_EXCEPTION
+	EventLine       Event = C.PyTrace_LINE
+	EventReturn     Event = C.PyTrace_RETURN
+	EventCCall      Event = C.PyTrace_C_CALL
+	EventCException Event = C.PyTrace_C_EXCEPTION
+	EventCReturn    Event = C.PyTrace_C_RETURN
+	EventOpcode     Event = C.PyTrace_OPCODE
+)
+
+var eventNames = [...]string{
+	EventCall:       "call",
+	EventException:  "exception",
+	EventLine:       "line",
+	EventReturn:     "return",
+	EventCCall:      "c_call",
+	EventCException: "c_exception",
+	EventCReturn:    "c_return",
+	EventOpcode:     "opcode",
+}
+
+// String returns the name Python uses for the event, as in sys.settrace.
+func (e Event) String() string {
+	if e >= 0 && int(e) < len(eventNames) {
+		return eventNames[e]
+	}
+	return "unknown"
+}
+
+// Frame is a Python execution frame. The frames passed to a profiler or
+// tracer function, and the ones returned by their Back method, are only
+// valid until the function returns.
+type Frame struct {
+	frame *C.PyFrameObject
+	ref   Object // owns frame for the frames returned by Back
+}
+
+// Nil reports whether f is the missing frame returned by Back for the
+// outermost frame.
+func (f Frame) Nil() bool {
+	return f.frame == nil
+}
+
+// Filename returns the file name of the code running in the frame.
+func (f Frame) Filename() string {
+	return newObject(C.frameFilename(f.frame)).String()
+}
+
+// Line returns the line the frame is executing.
+func (f Frame) Line() int {
+	return int(C.PyFrame_GetLineNumber(f.frame))
+}
+
+// Function returns the name of the function running in the frame, qualified
+// with its class on Python 3.11 and later. It is "<module>" for module code.
+func (f Frame) Function() string {
+	return newObject(C.frameFunction(f.frame)).String()
+}
+
+// FirstLine returns the line where the function running in the frame starts.
+func (f Frame) FirstLine() int {
+	return int(C.frameFirstLine(f.frame))
+}
+
+// Locals returns a snapshot of the frame's local variables.
+func (f Frame) Locals() Dict {
+	return Dict{must(tryObject(C.frameLocals(f.frame)))}
+}
+
+// Back returns the calling frame, which is Nil for the outermost frame.
+func (f Frame) Back() Frame {
+	back := C.PyFrame_GetBack(f.frame)
+	if back == nil {
+		return Frame{}
+	}
+	return Frame{frame: back, ref: newObject((*C.PyObject)(unsafe.Pointer(back)))}
+}
+
+// ProfileFunc is the function called by SetProfiler and SetTracer. arg is
+// the return value for EventReturn, the called function for the EventC*
+// events, the (type, value, traceback) tuple for EventException and None
+// otherwise.
+type ProfileFunc func(frame Frame, event Event, arg Object)
+
+// SetProfiler calls fn for the calls and returns of Python and C functions
+// run by the calling thread, like sys.setprofile. A nil fn removes the
+// profiler. The function is shared by all threads of the current interpreter
+// that set one. A panic in fn is raised into Python as GoPanic and removes the
+// profiler of the thread.
+func SetProfiler(fn ProfileFunc) {
+	gd := getGlobalData()
+	gd.setProfileFunc(&gd.profiler, fn)
+	C.setProfile(boolToInt(fn != nil))
+}
+
+// SetTracer calls fn for the calls, lines, returns and exceptions of Python
+// code run by the calling thread, like sys.settrace, and is otherwise like
+// SetProfiler.
+func SetTracer(fn ProfileFunc) {
+	gd := getGlobalData()
+	gd.setProfileFunc(&gd.tracer, fn)
+	C.setTrace(boolToInt(fn != nil))
+}
+
+func boolToInt(b bool) C.int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func (gd *globalData) setProfileFunc(field *ProfileFunc, fn ProfileFunc) {
+	gd.mu.Lock()
+	defer gd.mu.Unlock()
+	*field = fn
+}
+
+func (gd *globalData) profileFunc(field *ProfileFunc) ProfileFunc {
+	gd.mu.RLock()
+	defer gd.mu.RUnlock()
+	return *field
+}
+
+func callProfileFunc(fn ProfileFunc, frame *C.PyFrameObject, what C.int, arg *C.PyObject) (ret C.int) {
+	if fn == nil {
+		return 0
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			raiseGoPanic(r)
+			ret = -1
+		}
+	}()
+	argObj := None()
+	if arg != nil {
+		argObj = newObjectRef(arg)
+	}
+	fn(Frame{frame: frame}, Event(what), argObj)
+	return 0
+}
+
+//export goProfileFunc
+func goProfileFunc(frame *C.PyFrameObject, what C.int, arg *C.PyObject) C.int {
+	gd := getGlobalData()
+	return callProfileFunc(gd.profileFunc(&gd.profiler), frame, what, arg)
+}
+
+//export goTraceFunc
+func goTraceFunc(frame *C.PyFrameObject, what C.int, arg *C.PyObject) C.int {
+	gd := getGlobalData()
+	return callProfileFunc(gd.profileFunc(&gd.tracer), frame, what, arg)
+}
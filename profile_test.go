@@ -0,0 +1,169 @@
+package gp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestSetProfiler(t *testing.T) {
+	setupTest(t)
+	if err := RunString("def add(a, b):\n    return len([a]) + b\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	var events []string
+	var locals map[string]int
+	var caller string
+	SetProfiler(func(frame Frame, event Event, arg Object) {
+		if frame.Function() != "add" {
+			return
+		}
+		switch event {
+		case EventCall:
+			locals = make(map[string]int)
+			frame.Locals().Items()(func(k, v Object) bool {
+				locals[k.String()] = v.AsLong().Int()
+				return true
+			})
+			back := frame.Back()
+			caller = back.Function()
+			if frame.Filename() != "<string>" || frame.FirstLine() != 1 || !back.Back().Nil() {
+				t.Errorf("frame %s:%d, back of back not nil", frame.Filename(), frame.FirstLine())
+			}
+			events = append(events, event.String())
+		case EventCCall:
+			events = append(events, event.String()+":"+arg.AttrString("__name__").String())
+		case EventReturn:
+			events = append(events, event.String()+":"+arg.String())
+		}
+	})
+	err := RunString("add(1, 2)")
+	SetProfiler(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.Join(events, " "), "call c_call:len return:3"; got != want {
+		t.Errorf("events = %q, want %q", got, want)
+	}
+	if locals["a"] != 1 || locals["b"] != 2 || len(locals) != 2 {
+		t.Errorf("locals = %v", locals)
+	}
+	if caller != "<module>" {
+		t.Errorf("caller = %q, want <module>", caller)
+	}
+
+	events = nil
+	if err := RunString("add(1, 2)"); err != nil || events != nil {
+		t.Errorf("events after SetProfiler(nil) = %v, %v", events, err)
+	}
+
+	SetProfiler(func(Frame, Event, Object) { panic("profiler") })
+	err = RunString("add(1, 2)")
+	SetProfiler(nil)
+	if !ErrorMatches(err, GoPanicType()) {
+		t.Errorf("RunString() with a panicking profiler = %v, want GoPanic", err)
+	}
+}
+
+func TestSetTracer(t *testing.T) {
+	setupTest(t)
+	if err := RunString("def count(n):\n    total = 0\n    for i in range(n):\n        total += i\n    return total\n"); err != nil {
+		t.Fatal(err)
+	}
+	lines := make(map[int]int)
+	SetTracer(func(frame Frame, event Event, arg Object) {
+		if event == EventLine && frame.Function() == "count" {
+			lines[frame.Line()]++
+		}
+	})
+	err := RunString("count(3)")
+	SetTracer(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines[2] != 1 || lines[4] != 3 || lines[5] != 1 {
+		t.Errorf("line events = %v", lines)
+	}
+}
+
+// profileStrings returns the string table of a gzipped pprof profile and
+// its number of samples.
+func profileStrings(t *testing.T, data []byte) (strs []string, samples int) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	varint := func() uint64 {
+		var v uint64
+		for shift := 0; ; shift += 7 {
+			c := raw[0]
+			raw = raw[1:]
+			v |= uint64(c&0x7f) << shift
+			if c < 0x80 {
+				return v
+			}
+		}
+	}
+	for len(raw) > 0 {
+		key := varint()
+		switch key & 7 {
+		case 0:
+			varint()
+		case 2:
+			n := varint()
+			switch key >> 3 {
+			case pprofSample:
+				samples++
+			case pprofStringTable:
+				strs = append(strs, string(raw[:n]))
+			}
+			raw = raw[n:]
+		default:
+			t.Fatalf("unexpected wire type in %x", key)
+		}
+	}
+	return strs, samples
+}
+
+func TestCPUProfile(t *testing.T) {
+	setupTest(t)
+	var buf bytes.Buffer
+	if err := StartCPUProfile(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if err := StartCPUProfile(io.Discard); err == nil {
+		t.Error("second StartCPUProfile() should fail")
+	}
+	code := `
+import time
+def hot_function():
+    deadline = time.monotonic() + 0.3
+    while time.monotonic() < deadline:
+        sum(range(100))
+hot_function()
+`
+	if err := RunString(code); err != nil {
+		t.Fatal(err)
+	}
+	if err := StopCPUProfile(); err != nil {
+		t.Fatal(err)
+	}
+
+	strs, samples := profileStrings(t, buf.Bytes())
+	if samples == 0 {
+		t.Fatal("profile has no samples")
+	}
+	joined := strings.Join(strs, "\n")
+	for _, want := range []string{"samples", "cpu", "nanoseconds", "hot_function", "[module]", "<string>"} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("profile strings %q miss %q", strs, want)
+		}
+	}
+}
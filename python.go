@@ -13,13 +13,26 @@ import (
 
 type cPyObject = C.PyObject
 
+// Initialize initializes Python with its default configuration and locks
+// the calling goroutine to its OS thread, which holds the GIL. Unlike
+// InitializeWithConfig, it ignores the VIRTUAL_ENV environment variable;
+// use InitializeWithConfig(Config{}) to run in the active virtual
+// environment.
 func Initialize() {
 	runtime.LockOSThread()
 	C.Py_Initialize()
+	startPython()
+}
+
+// startPython sets up the package once Python is initialized on the calling
+// thread.
+func startPython() {
 	initGlobal()
+	setPythonRunning(true)
 }
 
 func Finalize() {
+	setPythonRunning(false)
 	markFinished()
 	r := C.Py_FinalizeEx()
 	cleanupGlobal()
@@ -80,7 +93,7 @@ func MainModule() Module {
 }
 
 func None() Object {
-	return newObject(C.Py_None)
+	return newObjectRef(C.Py_None)
 }
 
 func Nil() Object {
@@ -100,13 +113,14 @@ func RunString(code string) error {
 		return err
 	}
 
-	ret := EvalCode(codeObj, dict, dict)
-	if ret.Nil() {
+	ret := C.PyEval_EvalCode(codeObj.cpyObj(), dict.cpyObj(), dict.cpyObj())
+	if ret == nil {
 		if err := FetchError(); err != nil {
 			return err
 		}
 		return fmt.Errorf("failed to execute code")
 	}
+	C.Py_DecRef(ret)
 	return nil
 }
 
@@ -126,3 +140,10 @@ func check(b bool, msg string) {
 		panic(msg)
 	}
 }
+
+func must[T any](v T, err error) T {
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
@@ -13,7 +13,7 @@ var (
 func setupTest(t *testing.T) {
 	testMutex.Lock()
 	Initialize()
-	getGlobalData().alwaysDecRef = true
+	SetDecRefPolicy(DecRefPolicy{BatchSize: 1})
 	t.Cleanup(func() {
 		runtime.GC()
 		Finalize()
@@ -21,6 +21,17 @@ func setupTest(t *testing.T) {
 	})
 }
 
+// evalString evaluates the Python expression code in the __main__ module.
+func evalString(t *testing.T, code string) Object {
+	t.Helper()
+	compiled, err := CompileString(code, "<string>", EvalInput)
+	if err != nil {
+		t.Fatal(err)
+	}
+	globals := MainModule().Dict()
+	return EvalCode(compiled, globals, globals)
+}
+
 func TestRunString(t *testing.T) {
 	setupTest(t)
 	tests := []struct {
@@ -0,0 +1,110 @@
+package gp
+
+/*
+#include <Python.h>
+*/
+import "C"
+
+import (
+	"runtime"
+	"sync/atomic"
+)
+
+// Scope collects the objects created while it is active, see WithScope.
+type Scope struct {
+	objects map[*pyObject]struct{}
+	parent  *Scope
+	ident   C.ulong
+}
+
+// WithScope calls fn and then closes every object created by the calling
+// goroutine while fn runs, except those passed to Scope.Keep. It releases
+// large intermediate results without waiting for the Go garbage collector:
+//
+//	var total int
+//	gp.WithScope(func(s *gp.Scope) {
+//		data := np.Call("ones", 1<<20)
+//		total = data.Call("sum").AsLong().Int()
+//	})
+//
+// Scopes may be nested; objects kept in an inner scope move to the outer
+// one. Objects cached for later calls, such as lazily imported modules, must
+// be passed to Unscoped.
+func WithScope(fn func(s *Scope)) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	gd := getGlobalData()
+	s := &Scope{
+		objects: make(map[*pyObject]struct{}),
+		ident:   C.PyThread_get_thread_ident(),
+	}
+	gd.pushScope(s)
+	defer func() {
+		gd.popScope(s)
+		for o := range s.objects {
+			Object{o}.Close()
+		}
+	}()
+	fn(s)
+}
+
+// Keep excludes objs from being closed when the scope ends, so they can be
+// used after WithScope returns.
+func (s *Scope) Keep(objs ...Objecter) {
+	for _, obj := range objs {
+		o := obj.object().pyObject
+		if _, ok := s.objects[o]; !ok {
+			continue
+		}
+		delete(s.objects, o)
+		if s.parent != nil {
+			s.parent.objects[o] = struct{}{}
+		}
+	}
+}
+
+// Unscoped removes obj from all scopes of the calling goroutine, so it is
+// only released by the garbage collector or Close. Use it for objects cached
+// beyond the current call:
+//
+//	if math_.Nil() {
+//		math_ = gp.Unscoped(gp.ImportModule("math"))
+//	}
+func Unscoped[T Objecter](obj T) T {
+	o := obj.object().pyObject
+	for s := getGlobalData().currentScope(); s != nil; s = s.parent {
+		delete(s.objects, o)
+	}
+	return obj
+}
+
+func (gd *globalData) pushScope(s *Scope) {
+	gd.mu.Lock()
+	defer gd.mu.Unlock()
+	s.parent = gd.scopes[s.ident]
+	gd.scopes[s.ident] = s
+	atomic.AddInt32(&gd.numScopes, 1)
+}
+
+func (gd *globalData) popScope(s *Scope) {
+	gd.mu.Lock()
+	defer gd.mu.Unlock()
+	if s.parent != nil {
+		gd.scopes[s.ident] = s.parent
+	} else {
+		delete(gd.scopes, s.ident)
+	}
+	atomic.AddInt32(&gd.numScopes, -1)
+}
+
+// currentScope returns the innermost scope of the calling thread, if any.
+func (gd *globalData) currentScope() *Scope {
+	if gd == nil || atomic.LoadInt32(&gd.numScopes) == 0 {
+		return nil
+	}
+	ident := C.PyThread_get_thread_ident()
+	gd.mu.RLock()
+	defer gd.mu.RUnlock()
+	return gd.scopes[ident]
+}
@@ -0,0 +1,83 @@
+package gp
+
+import (
+	"testing"
+)
+
+func TestWithScope(t *testing.T) {
+	setupTest(t)
+	SetDecRefPolicy(DecRefPolicy{BatchSize: DefaultDecRefBatchSize})
+	if err := RunString(`
+import weakref
+
+class Thing:
+    pass
+
+refs = []
+
+def make():
+    t = Thing()
+    refs.append(weakref.ref(t))
+    return t
+
+def alive():
+    return sum(1 for r in refs if r() is not None)
+`); err != nil {
+		t.Fatal(err)
+	}
+	main := MainModule()
+	alive := func() int {
+		return main.AttrFunc("alive").Call().AsLong().Int()
+	}
+
+	var kept, nested, cached Object
+	WithScope(func(s *Scope) {
+		WithScope(func(*Scope) {
+			cached = Unscoped(main.AttrFunc("make").Call())
+		})
+		for i := 0; i < 10; i++ {
+			main.AttrFunc("make").Call()
+		}
+		kept = main.AttrFunc("make").Call()
+		s.Keep(kept)
+		WithScope(func(inner *Scope) {
+			nested = main.AttrFunc("make").Call()
+			inner.Keep(nested)
+		})
+		if got := alive(); got != 13 {
+			t.Errorf("alive() inside scope = %d, want 13", got)
+		}
+	})
+	// Only the object kept by the outer scope and the unscoped one survive;
+	// the one kept by the inner scope was released by the outer one.
+	if got := alive(); got != 2 {
+		t.Errorf("alive() after scope = %d, want 2", got)
+	}
+	if kept.cpyObj() == nil || nested.cpyObj() != nil {
+		t.Error("only the kept object should still be open")
+	}
+
+	kept.Close()
+	kept.Close()
+	cached.Close()
+	if got := alive(); got != 0 {
+		t.Errorf("alive() after Close = %d, want 0", got)
+	}
+
+	// Closing an object that Go doesn't own is a no-op.
+	KeyError.Close()
+	if KeyError.cpyObj() == nil {
+		t.Error("KeyError should not be closed")
+	}
+
+	func() {
+		defer func() { recover() }()
+		WithScope(func(s *Scope) {
+			main.AttrFunc("make").Call()
+			panic("boom")
+		})
+	}()
+	if got := alive(); got != 0 {
+		t.Errorf("alive() after panicking scope = %d, want 0", got)
+	}
+}
@@ -0,0 +1,121 @@
+package gp
+
+/*
+#include <Python.h>
+*/
+import "C"
+
+import "reflect"
+
+// Set represents a Python set object.
+type Set struct {
+	Object
+}
+
+func newSet(obj *cPyObject) Set {
+	return Set{newObject(obj)}
+}
+
+// MakeSet returns a set of the items converted with From. It panics if an
+// item isn't hashable.
+func MakeSet(items ...any) Set {
+	set := newSet(C.PySet_New(nil))
+	for _, item := range items {
+		set.Add(item)
+	}
+	return set
+}
+
+// Add adds item to the set. It panics if item isn't hashable.
+func (s Set) Add(item any) {
+	if C.PySet_Add(s.obj, From(item).obj) < 0 {
+		panic(FetchError())
+	}
+}
+
+// Discard removes item from the set if present and reports whether it was.
+func (s Set) Discard(item any) bool {
+	r := C.PySet_Discard(s.obj, From(item).obj)
+	if r < 0 {
+		panic(FetchError())
+	}
+	return r == 1
+}
+
+func (s Set) Contains(item any) bool {
+	return setContains(s.Object, item)
+}
+
+func (s Set) Len() int {
+	return int(C.PySet_Size(s.obj))
+}
+
+// Items returns an iterator over the items of the set, in no particular
+// order. The set must not change size during the iteration.
+func (s Set) Items() func(func(Object) bool) {
+	return setItems(s.Object)
+}
+
+// FrozenSet represents a Python frozenset object.
+type FrozenSet struct {
+	Object
+}
+
+// MakeFrozenSet returns a frozenset of the items converted with From. It
+// panics if an item isn't hashable.
+func MakeFrozenSet(items ...any) FrozenSet {
+	return FrozenSet{must(tryObject(C.PyFrozenSet_New(MakeSet(items...).obj)))}
+}
+
+func (s FrozenSet) Contains(item any) bool {
+	return setContains(s.Object, item)
+}
+
+func (s FrozenSet) Len() int {
+	return int(C.PySet_Size(s.obj))
+}
+
+// Items returns an iterator over the items of the frozenset, in no
+// particular order.
+func (s FrozenSet) Items() func(func(Object) bool) {
+	return setItems(s.Object)
+}
+
+// setContains reports whether the set or frozenset s contains item. An
+// unhashable item isn't contained.
+func setContains(s Object, item any) bool {
+	r := C.PySet_Contains(s.obj, From(item).obj)
+	if r < 0 {
+		C.PyErr_Clear()
+		return false
+	}
+	return r == 1
+}
+
+func setItems(s Object) func(func(Object) bool) {
+	return func(fn func(Object) bool) {
+		iter := must(tryObject(C.PyObject_GetIter(s.obj)))
+		for {
+			item := C.PyIter_Next(iter.obj)
+			if item == nil {
+				if err := FetchError(); err != nil {
+					panic(err)
+				}
+				return
+			}
+			if !fn(newObject(item)) {
+				return
+			}
+		}
+	}
+}
+
+// fromSet converts a map[K]struct{} to a set of its keys.
+func fromSet(v reflect.Value) Set {
+	set := newSet(C.PySet_New(nil))
+	iter := v.MapRange()
+	for iter.Next() {
+		set.Add(iter.Key().Interface())
+	}
+	return set
+}
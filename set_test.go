@@ -0,0 +1,117 @@
+package gp
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestSet(t *testing.T) {
+	setupTest(t)
+	s := MakeSet(1, "a", 2.5)
+	if s.Len() != 3 {
+		t.Errorf("Len() = %d, want 3", s.Len())
+	}
+	s.Add("b")
+	s.Add(1)
+	if s.Len() != 4 || !s.Contains("b") || !s.Contains(1) {
+		t.Errorf("set after Add = %v", s)
+	}
+	if !s.Discard("a") || s.Discard("a") || s.Contains("a") {
+		t.Errorf("set after Discard = %v", s)
+	}
+	if s.Contains([]int{1}) {
+		t.Error("Contains(unhashable) = true")
+	}
+	if !s.IsSet() || s.IsFrozenSet() {
+		t.Error("MakeSet() is not a set")
+	}
+
+	var items []string
+	MakeSet("x", "y", "z").Items()(func(item Object) bool {
+		items = append(items, item.String())
+		return true
+	})
+	sort.Strings(items)
+	if !reflect.DeepEqual(items, []string{"x", "y", "z"}) {
+		t.Errorf("Items() = %v", items)
+	}
+
+	func() {
+		defer func() {
+			if r := recover(); r == nil || !ErrorMatches(r.(error), TypeError) {
+				t.Errorf("Add(unhashable) panic = %v", r)
+			}
+		}()
+		s.Add(MakeList(1))
+	}()
+}
+
+func TestFrozenSet(t *testing.T) {
+	setupTest(t)
+	s := MakeFrozenSet("a", "b", "a")
+	if s.Len() != 2 || !s.Contains("a") || s.Contains("c") || !s.IsFrozenSet() {
+		t.Errorf("MakeFrozenSet() = %v", s)
+	}
+	n := 0
+	s.Items()(func(Object) bool {
+		n++
+		return false
+	})
+	if n != 1 {
+		t.Errorf("Items() stopped after %d items, want 1", n)
+	}
+	if _, err := As[FrozenSet](MakeSet().Object); err == nil || err.Error() != "expected frozenset, got set" {
+		t.Errorf("As[FrozenSet](set) error = %v", err)
+	}
+	if fs, err := As[FrozenSet](s.Object); err != nil || fs.Len() != 2 {
+		t.Errorf("As[FrozenSet]() = %v, %v", fs, err)
+	}
+}
+
+func TestSetConversion(t *testing.T) {
+	setupTest(t)
+	main := MainModule()
+	allowed := map[string]struct{}{"read": {}, "write": {}}
+	main.AddObject("allowed", From(allowed))
+	main.AddMethod("grant", func(perms map[string]struct{}) int {
+		return len(perms)
+	}, "")
+	if err := RunString(`
+assert isinstance(allowed, set), type(allowed)
+assert {"read"}.issubset(allowed)
+assert allowed == {"read", "write"}
+assert grant({"a", "b", "c"}) == 3
+assert grant(frozenset({"a"})) == 1
+`); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := To[map[string]struct{}](main.Dict().Get(MakeStr("allowed")))
+	if err != nil || !reflect.DeepEqual(got, allowed) {
+		t.Errorf("To[map[string]struct{}]() = %v, %v", got, err)
+	}
+	if _, err := To[map[int]struct{}](MakeSet(1, "x").Object); err == nil || err.Error() != "field `{'x'}`: expected int, got str" {
+		t.Errorf("To[map[int]struct{}](mixed) error = %v", err)
+	}
+	if _, err := To[map[int]struct{}](MakeList(1).Object); err == nil || err.Error() != "expected set, got list" {
+		t.Errorf("To[map[int]struct{}](list) error = %v", err)
+	}
+	v, err := To[any](MakeFrozenSet(1, "a").Object)
+	if want := map[any]struct{}{1: {}, "a": {}}; err != nil || !reflect.DeepEqual(v, want) {
+		t.Errorf("To[any](frozenset) = %#v, %v", v, err)
+	}
+	for _, item := range []Object{MakeTuple(1, 2).Object, MakeFrozenSet(1).Object} {
+		v, err := To[any](MakeSet(item).Object)
+		m, ok := v.(map[any]struct{})
+		if err != nil || !ok || len(m) != 1 {
+			t.Errorf("To[any]({%v}) = %#v, %v", item, v, err)
+			continue
+		}
+		for k := range m {
+			if key, ok := k.(Object); !ok || !key.Equals(item) {
+				t.Errorf("To[any]({%v}) has %#v", item, k)
+			}
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package gp
+
+/*
+#include <Python.h>
+*/
+import "C"
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// ObjectStats is a snapshot of the Python objects referenced from Go,
+// returned by Stats.
+type ObjectStats struct {
+	// Objects is the number of live Object wrappers owning a reference.
+	Objects int
+	// Holders is the number of Go values held by Python objects of types
+	// added with Module.AddType.
+	Holders int
+	// PendingDecRefs is the number of references released by finalizers
+	// but not yet decremented, see FlushDecRefs.
+	PendingDecRefs int
+	// TotalRefCount is sys.gettotalrefcount() on debug builds of Python and
+	// -1 otherwise.
+	TotalRefCount int
+
+	// ObjectsByType and ObjectsBySite count the live Object wrappers by
+	// Python type name and by the call site that created them. They only
+	// include objects created while tracking is enabled, see SetTracking.
+	ObjectsByType map[string]int
+	ObjectsBySite map[string]int
+	// HoldersByType counts Holders by Go type.
+	HoldersByType map[string]int
+	// PendingByType counts PendingDecRefs by Python type name.
+	PendingByType map[string]int
+}
+
+var tracking int32
+
+// SetTracking enables or disables recording the Python type and creation
+// call site of new objects for ObjectStats, and returns the previous
+// setting. Tracking has a noticeable cost and is meant for tests and
+// debugging.
+func SetTracking(enabled bool) (previous bool) {
+	var v int32
+	if enabled {
+		v = 1
+	}
+	return atomic.SwapInt32(&tracking, v) != 0
+}
+
+// Stats returns a snapshot of the objects referenced from Go in the current
+// interpreter. The calling thread must hold the GIL.
+func Stats() ObjectStats {
+	gd := getGlobalData()
+	stats := ObjectStats{
+		Objects:       int(atomic.LoadInt64(&gd.liveObjects)),
+		TotalRefCount: -1,
+		ObjectsByType: make(map[string]int),
+		ObjectsBySite: make(map[string]int),
+		HoldersByType: make(map[string]int),
+		PendingByType: make(map[string]int),
+	}
+
+	gd.tracked.mu.Lock()
+	for _, rec := range gd.tracked.objects {
+		stats.ObjectsByType[rec.typeName]++
+		stats.ObjectsBySite[rec.site]++
+	}
+	gd.tracked.mu.Unlock()
+
+	gd.mu.RLock()
+	for h := gd.holders.head; h != nil; h = h.next {
+		stats.Holders++
+		stats.HoldersByType[reflect.TypeOf(h.obj).String()]++
+	}
+	gd.mu.RUnlock()
+
+	gd.decRefList.mu.Lock()
+	for _, obj := range gd.decRefList.objects {
+		stats.PendingDecRefs++
+		stats.PendingByType[typeName(obj)]++
+	}
+	gd.decRefList.mu.Unlock()
+
+	if fn, err := ImportModule("sys").TryAttr("gettotalrefcount"); err == nil {
+		stats.TotalRefCount = Func{fn}.Call().AsLong().Int()
+	}
+	return stats
+}
+
+// ----------------------------------------------------------------------------
+
+type objectRecord struct {
+	typeName string
+	site     string
+}
+
+// trackedObjects is keyed by address so that tracking doesn't keep the
+// wrappers alive.
+type trackedObjects struct {
+	mu      sync.Mutex
+	objects map[uintptr]objectRecord
+}
+
+func (gd *globalData) objectCreated(o *pyObject) {
+	if gd == nil {
+		return
+	}
+	atomic.AddInt64(&gd.liveObjects, 1)
+	if atomic.LoadInt32(&tracking) == 0 {
+		return
+	}
+	rec := objectRecord{typeName: typeName(o.obj), site: callSite()}
+	gd.tracked.mu.Lock()
+	if gd.tracked.objects == nil {
+		gd.tracked.objects = make(map[uintptr]objectRecord)
+	}
+	gd.tracked.objects[uintptr(unsafe.Pointer(o))] = rec
+	gd.tracked.mu.Unlock()
+}
+
+// objectReleased is called from Close and from finalizers, which run on
+// their own goroutine.
+func (gd *globalData) objectReleased(o *pyObject) {
+	if gd == nil {
+		return
+	}
+	atomic.AddInt64(&gd.liveObjects, -1)
+	gd.tracked.mu.Lock()
+	delete(gd.tracked.objects, uintptr(unsafe.Pointer(o)))
+	gd.tracked.mu.Unlock()
+}
+
+func typeName(obj *C.PyObject) string {
+	return C.GoString(C.Py_TYPE(obj).tp_name)
+}
+
+var packageDir = func() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Dir(file)
+}()
+
+// callSite returns the innermost caller outside of this package, counting
+// its tests as outside.
+func callSite() string {
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	for {
+		f, more := frames.Next()
+		if filepath.Dir(f.File) != packageDir || strings.HasSuffix(f.File, "_test.go") {
+			return fmt.Sprintf("%s:%d", f.File, f.Line)
+		}
+		if !more {
+			return "unknown"
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package gp
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+type statsPoint struct {
+	X int
+}
+
+func TestStats(t *testing.T) {
+	setupTest(t)
+	SetDecRefPolicy(DecRefPolicy{BatchSize: DefaultDecRefBatchSize})
+	prev := SetTracking(true)
+	defer SetTracking(prev)
+
+	before := Stats()
+	if before.TotalRefCount != -1 {
+		t.Logf("debug build, total refcount %d", before.TotalRefCount)
+	}
+
+	var kept []Object
+	for i := 0; i < 5; i++ {
+		kept = append(kept, MakeList(i).Object)
+	}
+	m := MainModule()
+	m.AddType(statsPoint{}, nil, "StatsPoint", "")
+	point := m.AttrFunc("StatsPoint").Call()
+
+	stats := Stats()
+	if got := stats.Objects - before.Objects; got < 6 {
+		t.Errorf("Objects grew by %d, want at least 6", got)
+	}
+	if got := stats.ObjectsByType["list"] - before.ObjectsByType["list"]; got != 5 {
+		t.Errorf("ObjectsByType[list] grew by %d, want 5", got)
+	}
+	var site string
+	for s, n := range stats.ObjectsBySite {
+		if strings.Contains(s, "stats_test.go") && n >= 5 {
+			site = s
+		}
+	}
+	if site == "" {
+		t.Errorf("no call site in stats_test.go with 5 objects: %v", stats.ObjectsBySite)
+	}
+	if got := stats.HoldersByType["*gp.statsPoint"]; got != 1 {
+		t.Errorf("HoldersByType[*gp.statsPoint] = %d, want 1", got)
+	}
+
+	for _, o := range kept {
+		o.Close()
+	}
+	point.Close()
+	if got := Stats().ObjectsByType["list"]; got != before.ObjectsByType["list"] {
+		t.Errorf("ObjectsByType[list] after Close = %d, want %d", got, before.ObjectsByType["list"])
+	}
+	if got := Stats().HoldersByType["*gp.statsPoint"]; got != 0 {
+		t.Errorf("holders after Close = %d, want 0", got)
+	}
+
+	// Garbage collected objects are pending until flushed.
+	for i := 0; i < 10; i++ {
+		MakeList(i)
+	}
+	var pending int
+	for i := 0; i < 50 && pending < 10; i++ {
+		runtime.GC()
+		time.Sleep(time.Millisecond)
+		pending = Stats().PendingByType["list"]
+	}
+	if pending < 10 {
+		t.Errorf("PendingByType[list] = %d, want at least 10", pending)
+	}
+	FlushDecRefs()
+	if got := Stats().PendingDecRefs; got != 0 {
+		t.Errorf("PendingDecRefs after FlushDecRefs = %d, want 0", got)
+	}
+}
@@ -0,0 +1,253 @@
+package gp
+
+/*
+#include <Python.h>
+*/
+import "C"
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+	"unsafe"
+)
+
+// goStream is the file-like object that SetStdout, SetStderr and SetStdin
+// install in sys. Python calls its methods with the GIL held, so they don't
+// run concurrently.
+type goStream struct {
+	Encoding string    `py:"encoding,readonly"`
+	Errors   string    `py:"errors,readonly"`
+	Closed   bool      `py:"closed,readonly"`
+	Buffer   *goBuffer `py:"buffer,readonly"`
+
+	w io.Writer
+	r *bufio.Reader
+}
+
+// goBuffer is the binary stream of a goStream, its buffer attribute. Both
+// share the writer and reader of the stream.
+type goBuffer struct {
+	Closed bool `py:"closed,readonly"`
+
+	stream *goStream
+}
+
+func (s *goStream) Write(text string) (int, error) {
+	if err := s.check(s.w != nil, "writable"); err != nil {
+		return 0, err
+	}
+	if _, err := io.WriteString(s.w, text); err != nil {
+		return 0, err
+	}
+	return utf8.RuneCountInString(text), nil
+}
+
+func (s *goStream) Flush() error {
+	if err := s.check(true, ""); err != nil {
+		return err
+	}
+	if f, ok := s.w.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// Readline reads a line, or at most size characters of it.
+func (s *goStream) Readline(size ...*int) (string, error) {
+	return s.read(size, true, false)
+}
+
+// Read reads until EOF, or at most size characters.
+func (s *goStream) Read(size ...*int) (string, error) {
+	return s.read(size, false, false)
+}
+
+func (s *goStream) Close() error {
+	if s.Closed {
+		return nil
+	}
+	err := s.Flush()
+	s.Closed = true
+	s.Buffer.Closed = true
+	return err
+}
+
+func (s *goStream) Fileno() (int, error) {
+	return 0, NewError(ImportModule("io").Attr("UnsupportedOperation"), "fileno")
+}
+
+func (s *goStream) Readable() bool { return s.r != nil }
+func (s *goStream) Writable() bool { return s.w != nil }
+func (s *goStream) Isatty() bool   { return false }
+
+// check returns the error Python raises when the stream is closed, or lacks
+// the ability, such as "writable", that ok reports.
+func (s *goStream) check(ok bool, ability string) error {
+	if s.Closed {
+		return NewError(ValueError, "I/O operation on closed file.")
+	}
+	if !ok {
+		return NewError(ImportModule("io").Attr("UnsupportedOperation"), "not "+ability)
+	}
+	return nil
+}
+
+// read reads characters, or bytes if binary, until EOF, or the end of the
+// line if line, up to the optional size. A missing, None or negative size
+// means no limit.
+func (s *goStream) read(size []*int, line, binary bool) (string, error) {
+	if err := s.check(s.r != nil, "readable"); err != nil {
+		return "", err
+	}
+	if len(size) > 1 {
+		return "", NewError(TypeError, fmt.Sprintf("expected at most 1 argument, got %d", len(size)))
+	}
+	n := -1
+	if len(size) == 1 && size[0] != nil {
+		n = *size[0]
+	}
+	if n < 0 && !line {
+		data, err := io.ReadAll(s.r)
+		return string(data), err
+	}
+	var b strings.Builder
+	for i := 0; n < 0 || i < n; i++ {
+		var c rune
+		var err error
+		if binary {
+			var bc byte
+			bc, err = s.r.ReadByte()
+			c = rune(bc)
+		} else {
+			c, _, err = s.r.ReadRune()
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return b.String(), err
+		}
+		if binary {
+			b.WriteByte(byte(c))
+		} else {
+			b.WriteRune(c)
+		}
+		if line && c == '\n' {
+			break
+		}
+	}
+	return b.String(), nil
+}
+
+func (b *goBuffer) Write(data []byte) (int, error) {
+	if err := b.stream.check(b.stream.w != nil, "writable"); err != nil {
+		return 0, err
+	}
+	return b.stream.w.Write(data)
+}
+
+func (b *goBuffer) Flush() error { return b.stream.Flush() }
+func (b *goBuffer) Close() error { return b.stream.Close() }
+
+// Readline reads a line, or at most size bytes of it.
+func (b *goBuffer) Readline(size ...*int) ([]byte, error) {
+	line, err := b.stream.read(size, true, true)
+	return []byte(line), err
+}
+
+// Read reads until EOF, or at most size bytes.
+func (b *goBuffer) Read(size ...*int) ([]byte, error) {
+	data, err := b.stream.read(size, false, true)
+	return []byte(data), err
+}
+
+func (b *goBuffer) Fileno() (int, error) { return b.stream.Fileno() }
+func (b *goBuffer) Readable() bool       { return b.stream.Readable() }
+func (b *goBuffer) Writable() bool       { return b.stream.Writable() }
+func (b *goBuffer) Isatty() bool         { return false }
+
+// newStream returns a Python object for stream, registering its types in the
+// current interpreter on first use.
+func newStream(stream *goStream) Object {
+	stream.Encoding = "utf-8"
+	stream.Errors = "strict"
+	stream.Buffer = &goBuffer{stream: stream}
+	if _, ok := getGlobalData().pyType(reflect.TypeOf(goStream{})); !ok {
+		m := CreateModule("_gp")
+		m.AddType(goBuffer{}, nil, "GoBuffer",
+			"Binary stream of a GoStream.")
+		m.AddType(goStream{}, nil, "GoStream",
+			"File-like object backed by a Go io.Writer or io.Reader.")
+	}
+	return From(stream)
+}
+
+func setSysObject(name string, obj Object) {
+	cname := AllocCStr(name)
+	C.PySys_SetObject(cname, obj.obj)
+	C.free(unsafe.Pointer(cname))
+}
+
+// setSysStream sets sys.<name> to a stream, or back to sys.__<name>__ if
+// stream is nil.
+func setSysStream(name string, stream *goStream) {
+	if stream == nil {
+		setSysObject(name, ImportModule("sys").Attr("__"+name+"__"))
+		return
+	}
+	setSysObject(name, newStream(stream))
+}
+
+// SetStdout makes sys.stdout of the current interpreter write to w, which
+// receives the output of print and friends. If w has a Flush() error method,
+// it is called when Python flushes the stream. A nil w restores the original
+// sys.__stdout__.
+func SetStdout(w io.Writer) {
+	if w == nil {
+		setSysStream("stdout", nil)
+		return
+	}
+	setSysStream("stdout", &goStream{w: w})
+}
+
+// SetStderr is like SetStdout for sys.stderr, which receives the tracebacks
+// printed by Python, including the ones of PyErr_Print.
+func SetStderr(w io.Writer) {
+	if w == nil {
+		setSysStream("stderr", nil)
+		return
+	}
+	setSysStream("stderr", &goStream{w: w})
+}
+
+// SetStdin makes sys.stdin of the current interpreter read from r, which
+// input() then reads lines from. A nil r restores the original sys.__stdin__.
+func SetStdin(r io.Reader) {
+	if r == nil {
+		setSysStream("stdin", nil)
+		return
+	}
+	setSysStream("stdin", &goStream{r: bufio.NewReader(r)})
+}
+
+// CaptureOutput calls fn and returns what Python wrote to sys.stdout and
+// sys.stderr meanwhile, restoring the previous streams afterwards. Python
+// threads running while fn runs are captured too.
+func CaptureOutput(fn func()) (stdout, stderr string) {
+	sys := ImportModule("sys")
+	prevStdout, prevStderr := sys.Attr("stdout"), sys.Attr("stderr")
+	defer func() {
+		setSysObject("stdout", prevStdout)
+		setSysObject("stderr", prevStderr)
+	}()
+
+	var out, errOut strings.Builder
+	SetStdout(&out)
+	SetStderr(&errOut)
+	fn()
+	return out.String(), errOut.String()
+}
@@ -0,0 +1,152 @@
+package gp
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type flushWriter struct {
+	bytes.Buffer
+	flushes int
+}
+
+func (w *flushWriter) Flush() error {
+	w.flushes++
+	return nil
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestSetStdio(t *testing.T) {
+	setupTest(t)
+	var stdout flushWriter
+	var stderr bytes.Buffer
+	SetStdout(&stdout)
+	SetStderr(&stderr)
+	SetStdin(strings.NewReader("alice\nbob\nrest\nof input"))
+	defer func() {
+		SetStdout(nil)
+		SetStderr(nil)
+		SetStdin(nil)
+	}()
+
+	code := `
+import sys
+print("hello", "world")
+print("flushed", flush=True)
+assert sys.stdout.encoding == "utf-8"
+assert not sys.stdout.isatty()
+name = input("name? ")
+assert name == "alice", name
+assert sys.stdin.readline() == "bob\n"
+assert sys.stdin.read() == "rest\nof input"
+assert sys.stdin.readline() == ""
+print("oops", file=sys.stderr)
+`
+	if err := RunString(code); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := stdout.String(), "hello world\nflushed\nname? "; got != want {
+		t.Errorf("stdout = %q, want %q", got, want)
+	}
+	if stdout.flushes == 0 {
+		t.Error("Flush was not called")
+	}
+
+	// Tracebacks printed by Python go to sys.stderr.
+	if err := RunString("import traceback\ntry:\n    1/0\nexcept ZeroDivisionError:\n    traceback.print_exc()"); err != nil {
+		t.Fatal(err)
+	}
+	if got := stderr.String(); !strings.HasPrefix(got, "oops\n") || !strings.Contains(got, "ZeroDivisionError") {
+		t.Errorf("stderr = %q, want oops and a traceback", got)
+	}
+
+	SetStdout(failingWriter{})
+	err := RunString("print('lost')")
+	SetStdout(nil)
+	if err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Errorf("print to a failing writer = %v, want disk full error", err)
+	}
+	if err := RunString("import sys\nassert sys.stdout is sys.__stdout__"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestStreamFileMethods(t *testing.T) {
+	setupTest(t)
+	var stdout bytes.Buffer
+	SetStdout(&stdout)
+	SetStdin(strings.NewReader("héllo\nworld\nrest"))
+	defer func() {
+		SetStdout(nil)
+		SetStdin(nil)
+	}()
+
+	code := `
+import io, sys
+assert sys.stdout.errors == "strict"
+assert not sys.stdout.closed and not sys.stdout.buffer.closed
+sys.stdout.write("text ")
+assert sys.stdout.buffer.write(b"bytes\n") == 6
+try:
+    sys.stdout.fileno()
+    raise AssertionError("fileno() succeeded")
+except io.UnsupportedOperation:
+    pass
+try:
+    sys.stdout.read()
+    raise AssertionError("read() of stdout succeeded")
+except io.UnsupportedOperation:
+    pass
+
+assert sys.stdin.read(2) == "hé", "read(2)"
+assert sys.stdin.readline(2) == "ll"
+assert sys.stdin.readline(-1) == "o\n"
+assert sys.stdin.buffer.readline(3) == b"wor"
+assert sys.stdin.buffer.read(3) == b"ld\n"
+assert sys.stdin.read(None) == "rest"
+assert sys.stdin.read(1) == ""
+
+sys.stdin.close()
+assert sys.stdin.closed and sys.stdin.buffer.closed
+try:
+    sys.stdin.readline()
+    raise AssertionError("readline() of a closed stream succeeded")
+except ValueError:
+    pass
+`
+	if err := RunString(code); err != nil {
+		t.Fatal(err)
+	}
+	if got := stdout.String(); got != "text bytes\n" {
+		t.Errorf("stdout = %q", got)
+	}
+}
+
+func TestCaptureOutput(t *testing.T) {
+	setupTest(t)
+	var outer bytes.Buffer
+	SetStdout(&outer)
+	defer SetStdout(nil)
+
+	stdout, stderr := CaptureOutput(func() {
+		if err := RunString("import sys\nprint('captured')\nsys.stderr.write('warning\\n')"); err != nil {
+			t.Error(err)
+		}
+	})
+	if stdout != "captured\n" || stderr != "warning\n" {
+		t.Errorf("CaptureOutput() = %q, %q", stdout, stderr)
+	}
+	if err := RunString("print('after')"); err != nil {
+		t.Fatal(err)
+	}
+	if got := outer.String(); got != "after\n" {
+		t.Errorf("stdout after CaptureOutput = %q, want %q", got, "after\n")
+	}
+}